@@ -6,11 +6,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"os"
 	"sort"
 	"strings"
 
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/libs/kv"
 	"github.com/tendermint/tendermint/libs/log"
 	tmdb "github.com/tendermint/tm-db"
@@ -23,7 +26,7 @@ import (
 )
 
 const (
-	AMOAppVersion = "v1.9.1"
+	AMOAppVersion = "v1.10.0"
 )
 
 // protocol versions supported by this app,
@@ -31,14 +34,16 @@ var AMOProtocolVersions = map[uint64]AMOProtocol{
 	uint64(0x4): &AMOProtocolV4{},
 	uint64(0x5): &AMOProtocolV5{},
 	uint64(0x6): &AMOProtocolV6{},
+	uint64(0x7): &AMOProtocolV7{},
 }
 
 // protocol versions and app versions supporting them
 var AMOProtocolCompatMap = map[uint64]string{
 	uint64(0x3): "v1.6.x",
-	uint64(0x4): "v1.7.x, v1.8.x, v1.9.x",
-	uint64(0x5): "v1.8.x, v1.9.x",
-	uint64(0x6): "v1.9.x",
+	uint64(0x4): "v1.7.x, v1.8.x, v1.9.x, v1.10.x",
+	uint64(0x5): "v1.8.x, v1.9.x, v1.10.x",
+	uint64(0x6): "v1.9.x, v1.10.x",
+	uint64(0x7): "v1.10.x",
 }
 
 // Output are sorted by voting power.
@@ -89,9 +94,12 @@ func findValUpdates(oldVals, newVals abci.ValidatorUpdates) abci.ValidatorUpdate
 type AMOProtocol interface {
 	Version() uint64
 	ParseTx(txBytes []byte) (tx.Tx, error)
+	TxRegistry() tx.TxRegistry
+	QueryRoutes() QueryRoutes
+	// store changes to make on the upgrade to this version, nil if none
+	Migration() *ProtocolMigration
 	//Info(abci.RequestInfo) abci.ResponseInfo
 	//SetOption(abci.RequestSetOption) abci.ResponseSetOption
-	//Query(abci.RequestQuery) abci.ResponseQuery
 	//CheckTx(abci.RequestCheckTx) abci.ResponseCheckTx
 	//InitChain(abci.RequestInitChain) abci.ResponseInitChain
 	//BeginBlock(abci.RequestBeginBlock) abci.ResponseBeginBlock
@@ -116,6 +124,8 @@ type AMOApp struct {
 	// abstraction of internal DBs to the outer world
 	store               *astore.Store
 	checkpoint_interval int64 // NOTE: this is a tentative workaround
+	// branch of store on the last committed state, where CheckTx runs txs
+	checkStore *astore.Store
 
 	// runtime temporary variables
 	doValUpdate bool
@@ -125,6 +135,8 @@ type AMOApp struct {
 	staker          []byte
 	feeAccumulated  types.Currency
 	numDeliveredTxs int64
+	// sum of execution costs of txs delivered in the block
+	blockCost uint64
 
 	// penalty-related variables
 	pendingEvidences      []abci.Evidence
@@ -137,6 +149,19 @@ type AMOApp struct {
 
 	// version-specific protocol executer
 	proto AMOProtocol
+
+	// stops the node when it cannot proceed, e.g. at an upgrade height
+	// requiring a protocol version not supported by this software
+	halt func(msg string)
+}
+
+// haltNode closes the DBs before exiting, so that the node can restart from
+// the state committed last.
+func (app *AMOApp) haltNode(msg string) {
+	app.logger.Error(msg)
+	fmt.Fprintln(os.Stderr, msg)
+	app.Close()
+	os.Exit(1)
 }
 
 func NewAMOApp(checkpoint_interval int64, mdb, idxdb tmdb.DB, l log.Logger) *AMOApp {
@@ -161,6 +186,7 @@ func NewAMOApp(checkpoint_interval int64, mdb, idxdb tmdb.DB, l log.Logger) *AMO
 		store:               s,
 		checkpoint_interval: checkpoint_interval,
 	}
+	app.halt = app.haltNode
 
 	// load state, db and config
 	app.load()
@@ -187,25 +213,45 @@ func NewAMOApp(checkpoint_interval int64, mdb, idxdb tmdb.DB, l log.Logger) *AMO
 	return app
 }
 
+// resetCheckStore discards the changes made by CheckTx, which have to be made
+// again on the newly committed state.
+func (app *AMOApp) resetCheckStore() {
+	s, err := app.store.Branch()
+	if err != nil {
+		app.logger.Error("Failed to branch store for CheckTx", "err", err)
+		app.checkStore = nil
+		return
+	}
+	app.checkStore = s
+}
+
 func (app *AMOApp) loadAppConfig() error {
-	cfg, err := types.NewDefaultAMOAppConfig()
+	cfg, err := parseAppConfig(app.store.GetAppConfig())
 	if err != nil {
 		return err
 	}
 
-	b := app.store.GetAppConfig()
+	app.config = cfg
+
+	return nil
+}
+
+// parseAppConfig returns the config stored as *b* over the default one
+func parseAppConfig(b []byte) (types.AMOAppConfig, error) {
+	cfg, err := types.NewDefaultAMOAppConfig()
+	if err != nil {
+		return cfg, err
+	}
 
 	// if config exists
 	if len(b) > 0 {
 		err = json.Unmarshal(b, &cfg)
 		if err != nil {
-			return err
+			return cfg, err
 		}
 	}
 
-	app.config = cfg
-
-	return nil
+	return cfg, nil
 }
 
 func (app *AMOApp) load() {
@@ -225,6 +271,75 @@ func (app *AMOApp) load() {
 	app.store.RebuildIndex()
 }
 
+// checkChainID checks if tx is signed for this chain, since protocol v7
+func (app *AMOApp) checkChainID(t tx.Tx) error {
+	if app.proto.Version() < 0x7 || t.GetChainID() == app.state.ChainID {
+		return nil
+	}
+	return fmt.Errorf("chain id mismatch: %q, expected %q",
+		t.GetChainID(), app.state.ChainID)
+}
+
+// txSequence returns the sequence number of tx, which takes the place of the
+// replay preventer since protocol v7
+func (app *AMOApp) txSequence(t tx.Tx) uint64 {
+	if app.proto.Version() < 0x7 {
+		return 0
+	}
+	return t.GetSequence()
+}
+
+// checkMinFee checks if tx pays the minimum fee of the fee schedule, for the
+// execution cost given
+func (app *AMOApp) checkMinFee(t tx.Tx, cost uint64) error {
+	fee := t.GetFee()
+	minFee := tx.MinFee(&app.config, t, cost)
+	if fee.LessThan(minFee) {
+		return fmt.Errorf("fee %s is less than the minimum fee %s",
+			fee.String(), minFee.String())
+	}
+	return nil
+}
+
+// executeMetered executes tx on the store given while metering its execution
+// cost. Changes made by tx are reverted when its fee doesn't cover the cost or
+// the cost exceeds maxCost.
+func (app *AMOApp) executeMetered(s *astore.Store, t tx.Tx, maxCost uint64) (
+	uint32, string, []abci.Event, uint64) {
+	s.BeginTx()
+	defer s.EndTx()
+
+	rc, info, events := t.Execute(s)
+	cost := s.TxMeter().Cost()
+	if rc != code.TxCodeOK {
+		return rc, info, events, cost
+	}
+
+	err := app.checkMinFee(t, cost)
+	if err != nil {
+		s.RevertTx()
+		return code.TxCodeInsufficientFee, err.Error(), nil, cost
+	}
+	if cost > maxCost {
+		s.RevertTx()
+		info = fmt.Sprintf("execution cost %d exceeds the block cost limit", cost)
+		return code.TxCodeExceededBlockCost, info, nil, cost
+	}
+
+	return rc, info, events, cost
+}
+
+// remainingBlockCost returns the execution cost still available in the block
+func (app *AMOApp) remainingBlockCost() uint64 {
+	if app.config.MaxBlockCost == 0 {
+		return math.MaxUint64
+	}
+	if app.blockCost >= app.config.MaxBlockCost {
+		return 0
+	}
+	return app.config.MaxBlockCost - app.blockCost
+}
+
 func checkProtocolVersion(version uint64) error {
 	if _, ok := AMOProtocolVersions[version]; ok {
 		return nil
@@ -243,25 +358,61 @@ func (app *AMOApp) upgradeProtocol() []abci.Event {
 	if app.proto == nil { // fail-safe code for initialization
 		app.proto = AMOProtocolVersions[app.state.ProtocolVersion]
 	}
-	if app.state.Height != app.config.UpgradeProtocolHeight ||
-		app.config.UpgradeProtocolVersion == 0 {
+
+	var (
+		version uint64
+		name    string
+	)
+	// upgrade plan set by governance
+	plan := app.store.GetUpgradePlan(true)
+	if plan != nil && app.state.Height == plan.Height {
+		err := checkProtocolVersion(plan.Version)
+		if err != nil {
+			app.halt(fmt.Sprintf("UPGRADE %q NEEDED at height %d: "+
+				"protocol version %d is not supported by this software "+
+				"version %s. Please use a software version %s.",
+				plan.Name, plan.Height, plan.Version,
+				AMOAppVersion, plan.AppVersion))
+			return events
+		}
+		app.store.DeleteUpgradePlan()
+		version = plan.Version
+		name = plan.Name
+	} else if app.state.Height == app.config.UpgradeProtocolHeight &&
+		app.config.UpgradeProtocolVersion != 0 {
+		version = app.config.UpgradeProtocolVersion
+	} else {
+		return events
+	}
+
+	err := app.migrateTo(version)
+	if err != nil {
+		app.halt(fmt.Sprintf("Protocol migration to %d failed at height %d: %s",
+			version, app.state.Height, err.Error()))
 		return events
 	}
+
 	oldVersion := app.state.ProtocolVersion
-	app.state.ProtocolVersion = app.config.UpgradeProtocolVersion
+	app.state.ProtocolVersion = version
 	if app.state.ProtocolVersion > 4 {
-		app.store.SetProtocolVersion(app.config.UpgradeProtocolVersion)
+		app.store.SetProtocolVersion(version)
 	}
 	app.proto = AMOProtocolVersions[app.state.ProtocolVersion]
 	tx.StateProtocolVersion = app.state.ProtocolVersion
 
 	versionJson, _ := json.Marshal(app.state.ProtocolVersion)
-	events = append(events, abci.Event{
+	event := abci.Event{
 		Type: "protocol_upgrade",
 		Attributes: []kv.Pair{
 			{Key: []byte("version"), Value: versionJson},
 		},
-	})
+	}
+	if len(name) > 0 {
+		nameJson, _ := json.Marshal(name)
+		event.Attributes = append(event.Attributes,
+			kv.Pair{Key: []byte("name"), Value: nameJson})
+	}
+	events = append(events, event)
 	fmt.Printf("Protocol upgrade from %d to %d at height %d\n",
 		oldVersion, app.state.ProtocolVersion, app.state.Height)
 
@@ -289,6 +440,10 @@ func (app *AMOApp) InitChain(req abci.RequestInitChain) abci.ResponseInitChain {
 	if err != nil {
 		panic(err)
 	}
+	app.state.ChainID = req.ChainId
+	if app.state.ProtocolVersion >= 0x7 {
+		app.store.SetChainID(req.ChainId)
+	}
 
 	hash, version, err := app.store.Save()
 	if err != nil {
@@ -325,7 +480,8 @@ func (app *AMOApp) InitChain(req abci.RequestInitChain) abci.ResponseInitChain {
 	app.logger.Info("InitChain: new genesis app state applied.")
 
 	return abci.ResponseInitChain{
-		Validators: app.store.GetValidators(app.config.MaxValidators, false),
+		Validators: app.store.GetValidators(app.config.MaxValidators,
+			app.config.MaxVotingPowerRate, app.config.SelfStakeRule(), false),
 	}
 }
 
@@ -341,57 +497,17 @@ func (app *AMOApp) Query(reqQuery abci.RequestQuery) (resQuery abci.ResponseQuer
 		return resQuery
 	}
 
-	switch reqs[0] {
-	case "version":
-		resQuery = queryVersion(app)
-	case "config":
-		resQuery = queryAppConfig(app.config)
-	case "balance":
-		switch len(reqs) {
-		case 1:
-			resQuery = queryBalance(app.store, "", reqQuery.Data)
-		case 2:
-			resQuery = queryBalance(app.store, reqs[1], reqQuery.Data)
-		default:
-			resQuery.Code = code.QueryCodeBadPath
-			return resQuery
-		}
-	case "udc":
-		resQuery = queryUDC(app.store, reqQuery.Data)
-	case "udclock":
-		if len(reqs) != 2 {
-			resQuery.Code = code.QueryCodeBadPath
-			return resQuery
-		}
-		resQuery = queryUDCLock(app.store, reqs[1], reqQuery.Data)
-	case "stake":
-		resQuery = queryStake(app.store, reqQuery.Data)
-	case "delegate":
-		resQuery = queryDelegate(app.store, reqQuery.Data)
-	case "validator":
-		resQuery = queryValidator(app.store, reqQuery.Data)
-	case "hibernate":
-		resQuery = queryHibernate(app.store, reqQuery.Data)
-	case "storage":
-		resQuery = queryStorage(app.store, reqQuery.Data)
-	case "draft":
-		resQuery = queryDraft(app.store, reqQuery.Data)
-	case "vote":
-		resQuery = queryVote(app.store, reqQuery.Data)
-	case "parcel":
-		resQuery = queryParcel(app.store, reqQuery.Data)
-	case "request":
-		resQuery = queryRequest(app.store, reqQuery.Data)
-	case "usage":
-		resQuery = queryUsage(app.store, reqQuery.Data)
-	case "did":
-		resQuery = queryDIDEntry(app.store, reqQuery.Data)
-	case "vc":
-		resQuery = queryVCEntry(app.store, reqQuery.Data)
-	default:
+	// before protocol v4, serve queries as in protocol v4
+	routes := queryRoutesV4
+	if app.proto != nil {
+		routes = app.proto.QueryRoutes()
+	}
+	handler, ok := routes[reqs[0]]
+	if !ok {
 		resQuery.Code = code.QueryCodeBadPath
 		return resQuery
 	}
+	resQuery = handler(app, reqs[1:], reqQuery.Data)
 
 	app.logger.Debug("Query: "+reqQuery.Path, "query_data", reqQuery.Data,
 		"query_response", resQuery.GetLog())
@@ -402,6 +518,10 @@ func (app *AMOApp) Query(reqQuery abci.RequestQuery) (resQuery abci.ResponseQuer
 func (app *AMOApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBeginBlock) {
 	app.state.Height = req.Header.Height
 	tx.StateBlockHeight = app.state.Height
+	if len(app.state.ChainID) == 0 {
+		// chain initialized by an app not recording chain id
+		app.state.ChainID = req.Header.ChainID
+	}
 
 	// upgrade protocol version
 	evs := app.upgradeProtocol()
@@ -410,7 +530,8 @@ func (app *AMOApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBegi
 	// check if app's protocol version matches supported version
 	err := checkProtocolVersion(app.state.ProtocolVersion)
 	if err != nil {
-		panic(err)
+		app.halt(err.Error())
+		return
 	}
 
 	// migrate to 5
@@ -418,13 +539,19 @@ func (app *AMOApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBegi
 	//app.MigrateTo5()
 
 	app.doValUpdate = false
-	app.oldVals = app.store.GetValidators(app.config.MaxValidators, false)
+	app.oldVals = app.store.GetValidators(app.config.MaxValidators,
+		app.config.MaxVotingPowerRate, app.config.SelfStakeRule(), false)
 
 	proposer := req.Header.GetProposerAddress()
 
 	app.staker = app.store.GetHolderByValidator(proposer, false)
+	if app.staker == nil {
+		// proposer may still be signing with a key rotated in this block
+		app.staker = app.store.GetHolderByRetiredValidator(proposer, false)
+	}
 	app.feeAccumulated = *new(types.Currency).Set(0)
 	app.numDeliveredTxs = int64(0)
+	app.blockCost = 0
 
 	// blockchain modules
 	app.replayPreventer.Update(app.state.Height, app.config.BlockBindingWindow)
@@ -446,11 +573,28 @@ func (app *AMOApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBegi
 // - check parameter format
 // - check availability of binding tx to block
 // - check replay attack of txs which were processed before
+func parseErrorCode(err error) uint32 {
+	if parseErr, ok := err.(*tx.ParseError); ok {
+		return parseErr.Code
+	}
+	return code.TxCodeBadParam
+}
+
 func (app *AMOApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
 	t, err := app.proto.ParseTx(req.Tx)
 	if err != nil {
 		return abci.ResponseCheckTx{
-			Code:      code.TxCodeBadParam,
+			Code:      parseErrorCode(err),
+			Log:       err.Error(),
+			Info:      err.Error(),
+			Codespace: "amo",
+		}
+	}
+
+	err = app.checkChainID(t)
+	if err != nil {
+		return abci.ResponseCheckTx{
+			Code:      code.TxCodeBadChainID,
 			Log:       err.Error(),
 			Info:      err.Error(),
 			Codespace: "amo",
@@ -468,8 +612,27 @@ func (app *AMOApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
 		}
 	}
 
+	// fee is not a consensus rule before v7
+	if app.proto.Version() >= 0x7 {
+		err = app.checkMinFee(t, 0)
+		if err != nil {
+			return abci.ResponseCheckTx{
+				Code:      code.TxCodeInsufficientFee,
+				Log:       err.Error(),
+				Info:      err.Error(),
+				Codespace: "amo",
+			}
+		}
+	}
+
 	if req.Type == abci.CheckTxType_New {
-		if !t.Verify() {
+		verified := false
+		if app.proto.Version() >= 0x7 {
+			verified = t.VerifyStrict()
+		} else {
+			verified = t.Verify()
+		}
+		if !verified {
 			return abci.ResponseCheckTx{
 				Code:      code.TxCodeBadSignature,
 				Log:       "Signature verification failed",
@@ -479,17 +642,64 @@ func (app *AMOApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
 		}
 	}
 
-	err = app.replayPreventer.Check(req.Tx, t.GetLastHeight(), app.state.Height)
-	if err != nil {
+	// state saved other than by Commit, e.g. by InitChain
+	if app.proto.Version() >= 0x7 && (app.checkStore == nil ||
+		app.checkStore.GetMerkleVersion() != app.store.GetMerkleVersion()) {
+		app.resetCheckStore()
+	}
+
+	// tx having a sequence number is not bound to recent blocks, but has to
+	// follow the last sequence of the sender including the ones in mempool
+	seq := app.txSequence(t)
+	if seq > 0 {
+		last := app.store.GetSequence(t.GetSender(), true)
+		if app.checkStore != nil {
+			last = app.checkStore.GetSequence(t.GetSender(), false)
+		}
+		if seq <= last {
+			return abci.ResponseCheckTx{
+				Code:      code.TxCodeBadSequence,
+				Log:       "already used sequence",
+				Info:      "already used sequence",
+				Codespace: "amo",
+			}
+		}
+		if seq > last+1 {
+			return abci.ResponseCheckTx{
+				Code:      code.TxCodeBadSequence,
+				Log:       "sequence gap",
+				Info:      "sequence gap",
+				Codespace: "amo",
+			}
+		}
+	} else {
+		err = app.replayPreventer.Check(req.Tx, t.GetLastHeight(), app.state.Height)
+		if err != nil {
+			return abci.ResponseCheckTx{
+				Code:      code.TxCodeImproperTx,
+				Log:       err.Error(),
+				Info:      err.Error(),
+				Codespace: "amo",
+			}
+		}
+	}
+
+	rc, info := t.Check()
+	if rc != code.TxCodeOK {
 		return abci.ResponseCheckTx{
-			Code:      code.TxCodeImproperTx,
-			Log:       err.Error(),
-			Info:      err.Error(),
+			Code:      rc,
+			Log:       info,
+			Info:      info,
 			Codespace: "amo",
 		}
 	}
 
-	rc, info := t.Check()
+	if app.proto.Version() >= 0x7 && app.checkStore != nil {
+		rc, info = app.executeOnCheckStore(t)
+		if rc == code.TxCodeOK && seq > 0 {
+			app.checkStore.SetSequence(t.GetSender(), seq)
+		}
+	}
 
 	return abci.ResponseCheckTx{
 		Code:      rc,
@@ -499,27 +709,81 @@ func (app *AMOApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
 	}
 }
 
+// executeOnCheckStore pays fee and executes tx on the check store as DeliverTx
+// does, to reject txs doomed to fail before they enter mempool.
+func (app *AMOApp) executeOnCheckStore(t tx.Tx) (uint32, string) {
+	// check store holds the state committed last, on which tx would get
+	// executed in the next block
+	height := tx.StateBlockHeight
+	tx.StateBlockHeight = app.state.LastHeight + 1
+	defer func() { tx.StateBlockHeight = height }()
+
+	fee := t.GetFee()
+	balance := app.checkStore.GetBalance(t.GetSender(), false)
+
+	if balance.LessThan(&fee) {
+		return code.TxCodeNotEnoughBalance, "not enough balance to pay fee"
+	}
+
+	app.checkStore.SetBalance(t.GetSender(), balance.Sub(&fee))
+
+	// tx costing more than a block can take never gets delivered
+	maxCost := app.config.MaxBlockCost
+	if maxCost == 0 {
+		maxCost = math.MaxUint64
+	}
+	rc, info, _, _ := app.executeMetered(app.checkStore, t, maxCost)
+	if rc != code.TxCodeOK {
+		app.checkStore.SetBalance(t.GetSender(), balance)
+	}
+
+	return rc, info
+}
+
 func (app *AMOApp) DeliverTx(req abci.RequestDeliverTx) abci.ResponseDeliverTx {
 	t, err := app.proto.ParseTx(req.Tx)
 	if err != nil {
 		return abci.ResponseDeliverTx{
-			Code:      code.TxCodeBadParam,
+			Code:      parseErrorCode(err),
 			Log:       err.Error(),
 			Info:      err.Error(),
 			Codespace: "amo",
 		}
 	}
 
-	err = app.replayPreventer.Append(req.Tx, t.GetLastHeight(), app.state.Height)
+	err = app.checkChainID(t)
 	if err != nil {
 		return abci.ResponseDeliverTx{
-			Code:      code.TxCodeImproperTx,
+			Code:      code.TxCodeBadChainID,
 			Log:       err.Error(),
 			Info:      err.Error(),
 			Codespace: "amo",
 		}
 	}
 
+	// sequence is consumed even if the tx fails afterwards, as tx hash is
+	if seq := app.txSequence(t); seq > 0 {
+		if seq != app.store.GetSequence(t.GetSender(), false)+1 {
+			return abci.ResponseDeliverTx{
+				Code:      code.TxCodeBadSequence,
+				Log:       "unexpected sequence",
+				Info:      "unexpected sequence",
+				Codespace: "amo",
+			}
+		}
+		app.store.SetSequence(t.GetSender(), seq)
+	} else {
+		err = app.replayPreventer.Append(req.Tx, t.GetLastHeight(), app.state.Height)
+		if err != nil {
+			return abci.ResponseDeliverTx{
+				Code:      code.TxCodeImproperTx,
+				Log:       err.Error(),
+				Info:      err.Error(),
+				Codespace: "amo",
+			}
+		}
+	}
+
 	typeJson, _ := json.Marshal(t.GetType())
 	senderJson, _ := json.Marshal(t.GetSender())
 	events := []abci.Event{
@@ -532,6 +796,21 @@ func (app *AMOApp) DeliverTx(req abci.RequestDeliverTx) abci.ResponseDeliverTx {
 		},
 	}
 
+	// reject tx not paying even for its payload before charging the fee, as
+	// the execution cost is checked only after execution. fee is not a
+	// consensus rule before v7.
+	if app.proto.Version() >= 0x7 {
+		err = app.checkMinFee(t, 0)
+		if err != nil {
+			return abci.ResponseDeliverTx{
+				Code:      code.TxCodeInsufficientFee,
+				Log:       err.Error(),
+				Info:      err.Error(),
+				Codespace: "amo",
+			}
+		}
+	}
+
 	fee := t.GetFee()
 	balance := app.store.GetBalance(t.GetSender(), false)
 
@@ -547,13 +826,28 @@ func (app *AMOApp) DeliverTx(req abci.RequestDeliverTx) abci.ResponseDeliverTx {
 	app.store.SetBalance(t.GetSender(), balance.Sub(&fee))
 	app.feeAccumulated.Add(&fee)
 
-	rc, info, opEvents := t.Execute(app.store)
+	var (
+		rc       uint32
+		info     string
+		opEvents []abci.Event
+		cost     uint64
+	)
+	if app.proto.Version() >= 0x7 {
+		rc, info, opEvents, cost = app.executeMetered(app.store, t,
+			app.remainingBlockCost())
+		// block pays for the execution even if the tx fails
+		app.blockCost += cost
+	} else {
+		rc, info, opEvents = t.Execute(app.store)
+	}
 
 	// if the operation was not successful,
-	// change nothing and rollback the fee
+	// change nothing and rollback the fee, while the fee is charged since v7
 	if rc == code.TxCodeOK {
 		if t.GetType() == "stake" || t.GetType() == "withdraw" ||
-			t.GetType() == "delegate" || t.GetType() == "retract" {
+			t.GetType() == "delegate" || t.GetType() == "retract" ||
+			t.GetType() == "rotate_validator" ||
+			t.GetType() == "unlock_early" {
 			app.doValUpdate = true
 		}
 
@@ -564,7 +858,7 @@ func (app *AMOApp) DeliverTx(req abci.RequestDeliverTx) abci.ResponseDeliverTx {
 		events = append(events, opEvents...)
 		app.numDeliveredTxs += 1
 
-	} else {
+	} else if app.proto.Version() < 0x7 {
 		app.feeAccumulated.Sub(&fee)
 		app.store.SetBalance(t.GetSender(), balance)
 	}
@@ -573,6 +867,7 @@ func (app *AMOApp) DeliverTx(req abci.RequestDeliverTx) abci.ResponseDeliverTx {
 		Code:      rc,
 		Log:       info,
 		Info:      info,
+		GasUsed:   int64(cost),
 		Events:    events,
 		Codespace: "amo",
 	}
@@ -642,35 +937,110 @@ func (app *AMOApp) EndBlock(req abci.RequestEndBlock) (res abci.ResponseEndBlock
 	doValUpdate, evs, _ = blockchain.PenalizeConvicts(
 		app.store,
 		app.logger,
+		app.state.Height,
+		app.staker,
 		app.pendingEvidences,
 		lazyValidators,
 		app.config.WeightValidator, app.config.WeightDelegator,
 		app.config.PenaltyRatioM, app.config.PenaltyRatioL,
+		blockchain.PenaltyParams{
+			Ratios:         app.config.PenaltyRatios,
+			MaxEvidenceAge: app.config.MaxEvidenceAge,
+			ReporterRate:   app.config.PenaltyReporterRate,
+			ToPool:         app.config.PenaltyToPool,
+		},
 	)
 	res.Events = append(res.Events, evs...)
 	app.doValUpdate = app.doValUpdate || doValUpdate
 
+	var (
+		valUpdates abci.ValidatorUpdates
+		valEvents  []abci.Event
+	)
 	if app.doValUpdate {
 		app.doValUpdate = false
-		newVals := app.store.GetValidators(app.config.MaxValidators, false)
-		res.ValidatorUpdates = findValUpdates(app.oldVals, newVals)
+		valUpdates, valEvents = app.validatorUpdates(&app.config)
+	}
+	if app.proto.Version() < 0x7 {
+		res.ValidatorUpdates = valUpdates
+		res.Events = append(res.Events, valEvents...)
 	}
 
 	app.replayPreventer.Index(app.state.Height)
 
-	evs = app.store.ProcessDraftVotes(
-		app.state.NextDraftID-uint32(1),
-		app.config.MaxValidators,
-		app.config.DraftQuorumRate,
-		app.config.DraftPassRate,
-		app.config.DraftRefundRate,
-		false,
-	)
+	if app.proto.Version() >= 0x7 {
+		evs = app.store.ProcessDraftVotes(
+			app.config.MaxValidators,
+			app.config.SelfStakeRule(),
+			app.config.DraftQuorumRate,
+			app.config.DraftPassRate,
+			app.config.DraftRefundRate,
+			app.config.DraftVetoRate,
+			false,
+		)
+	} else {
+		evs = app.store.ProcessDraftVotesOf(
+			app.state.NextDraftID-uint32(1),
+			app.config.MaxValidators,
+			app.config.SelfStakeRule(),
+			app.config.DraftQuorumRate,
+			app.config.DraftPassRate,
+			app.config.DraftRefundRate,
+			app.config.DraftVetoRate,
+			false,
+		)
+	}
 	res.Events = append(res.Events, evs...)
 
+	if app.proto.Version() >= 0x7 {
+		// config applied in this block takes effect from the next block, and
+		// so does the validator set under the new config
+		working := app.store.GetWorkingAppConfig()
+		if !bytes.Equal(working, app.store.GetAppConfig()) {
+			cfg, err := parseAppConfig(working)
+			if err != nil {
+				app.logger.Error(err.Error())
+			} else {
+				valUpdates, valEvents = app.validatorUpdates(&cfg)
+			}
+		}
+		res.ValidatorUpdates = valUpdates
+		res.Events = append(res.Events, valEvents...)
+	}
+
 	return res
 }
 
+// validatorUpdates returns changes from the validator set known to the
+// consensus engine to the one under *cfg*, along with events on validators
+// dropped due to lack of self-stake.
+func (app *AMOApp) validatorUpdates(cfg *types.AMOAppConfig) (
+	abci.ValidatorUpdates, []abci.Event) {
+	events := []abci.Event{}
+	rule := cfg.SelfStakeRule()
+	newVals := app.store.GetValidators(cfg.MaxValidators,
+		cfg.MaxVotingPowerRate, rule, false)
+	for _, val := range app.oldVals {
+		var k ed25519.PubKeyEd25519
+		copy(k[:], val.PubKey.Data)
+		holder := app.store.GetHolderByValidator(k.Address(), false)
+		if holder == nil || app.store.IsEligible(holder, rule, false) {
+			continue
+		}
+		validatorJson, _ := json.Marshal(k.Address())
+		addressJson, _ := json.Marshal(crypto.Address(holder))
+		ev := abci.Event{
+			Type: "validator_ineligible",
+			Attributes: []kv.Pair{
+				{Key: []byte("validator"), Value: validatorJson},
+				{Key: []byte("address"), Value: addressJson},
+			},
+		}
+		events = append(events, ev)
+	}
+	return findValUpdates(app.oldVals, newVals), events
+}
+
 func (app *AMOApp) Commit() abci.ResponseCommit {
 	hash, version, err := app.store.Save()
 	if err != nil {
@@ -689,6 +1059,10 @@ func (app *AMOApp) Commit() abci.ResponseCommit {
 	tx.StateNextDraftID = app.state.NextDraftID
 	tx.StateProtocolVersion = app.state.ProtocolVersion
 
+	if app.proto.Version() >= 0x7 {
+		app.resetCheckStore()
+	}
+
 	return abci.ResponseCommit{Data: app.state.LastAppHash}
 }
 
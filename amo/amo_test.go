@@ -1,6 +1,8 @@
 package amo
 
 import (
+	"bytes"
+	"crypto/elliptic"
 	"encoding/hex"
 	"encoding/json"
 	"math/big"
@@ -78,13 +80,74 @@ func TestQueryDefault(t *testing.T) {
 	assert.Equal(t, code.QueryCodeBadPath, res.Code)
 }
 
+func TestQueryRoutes(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.store.SetDraft(1, &types.Draft{
+		Kind:       types.DraftKindText,
+		CloseCount: 10,
+		ApplyCount: 10,
+	})
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+	queryjson, _ := json.Marshal(uint32(1))
+
+	// before protocol v4
+	assert.Nil(t, app.proto)
+	res := app.Query(abci.RequestQuery{Path: "/version"})
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+
+	for _, version := range []uint64{0x4, 0x5, 0x6} {
+		app.proto = AMOProtocolVersions[version]
+		res = app.Query(abci.RequestQuery{Path: "/pool"})
+		assert.Equal(t, code.QueryCodeBadPath, res.Code)
+		res = app.Query(abci.RequestQuery{Path: "/stake/locked"})
+		assert.Equal(t, code.QueryCodeNoKey, res.Code) // served by /stake
+		res = app.Query(abci.RequestQuery{Path: "/draft", Data: queryjson})
+		assert.Equal(t, code.QueryCodeOK, res.Code)
+		assert.NotContains(t, string(res.Value), `"tally"`)
+	}
+
+	app.proto = AMOProtocolVersions[0x7]
+	res = app.Query(abci.RequestQuery{Path: "/pool"})
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	res = app.Query(abci.RequestQuery{Path: "/stake/unknown"})
+	assert.Equal(t, code.QueryCodeBadPath, res.Code)
+	res = app.Query(abci.RequestQuery{Path: "/draft", Data: queryjson})
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Contains(t, string(res.Value), `"tally"`)
+}
+
+func TestQueryTxTypes(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	req := abci.RequestQuery{Path: "/txtypes"}
+	res := app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, []byte("[]"), res.Value)
+
+	app.proto = AMOProtocolVersions[0x6]
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	var txTypes []string
+	assert.NoError(t, json.Unmarshal(res.Value, &txTypes))
+	assert.Contains(t, txTypes, "did.claim")
+	assert.NotContains(t, txTypes, "rotate_validator")
+
+	app.proto = AMOProtocolVersions[0x7]
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.NoError(t, json.Unmarshal(res.Value, &txTypes))
+	assert.Contains(t, txTypes, "did.claim")
+	assert.Contains(t, txTypes, "rotate_validator")
+}
+
 func TestQueryVersion(t *testing.T) {
 	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
 
 	req := abci.RequestQuery{Path: "/version"}
 	res := app.Query(req)
 	jsonstr1 := []byte(`{"app_version":"` + AMOAppVersion +
-		`","app_protocol_versions":[4,5,6],"state_protocol_version":3,` +
+		`","app_protocol_versions":[4,5,6,7],"state_protocol_version":3,` +
 		`"app_protocol_version":3}`)
 	assert.Equal(t, jsonstr1, res.GetValue())
 
@@ -99,7 +162,7 @@ func TestQueryVersion(t *testing.T) {
 	req = abci.RequestQuery{Path: "/version"}
 	res = app.Query(req)
 	jsonstr2 := []byte(`{"app_version":"` + AMOAppVersion +
-		`","app_protocol_versions":[4,5,6],"state_protocol_version":4,` +
+		`","app_protocol_versions":[4,5,6,7],"state_protocol_version":4,` +
 		`"app_protocol_version":4}`)
 	assert.Equal(t, jsonstr2, res.GetValue())
 }
@@ -500,6 +563,198 @@ func TestQueryValidator(t *testing.T) {
 	assert.Equal(t, code.QueryCodeOK, res.Code)
 }
 
+func TestQueryValidators(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.proto = AMOProtocolVersions[0x7]
+	app.config.MaxVotingPowerRate = 0.5
+
+	req := abci.RequestQuery{Path: "/validators"}
+	res := app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, []byte("[]"), res.Value)
+
+	holder1 := prepForGov(app.store, "val1", 1000)
+	prepForGov(app.store, "val2", 300)
+	prepForGov(app.store, "val3", 200)
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	var powers []types.VotingPower
+	err = json.Unmarshal(res.Value, &powers)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(powers))
+	assert.Equal(t, holder1, powers[0].Holder)
+	assert.Equal(t, int64(1000), powers[0].Power)
+	assert.Equal(t, int64(500), powers[0].CappedPower)
+	assert.Equal(t, int64(200), powers[2].Power)
+	assert.Equal(t, int64(200), powers[2].CappedPower)
+}
+
+func TestQueryCommunityPool(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.proto = AMOProtocolVersions[0x7]
+
+	req := abci.RequestQuery{Path: "/pool"}
+	res := app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, []byte(`"0"`), res.Value)
+
+	app.store.SetCommunityPool(new(types.Currency).Set(1234))
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, []byte(`"1234"`), res.Value)
+}
+
+func TestQueryDraftTally(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.proto = AMOProtocolVersions[0x7]
+	app.config.DraftQuorumRate = 0.5
+	app.config.DraftPassRate = 0.6
+
+	proposer := prepForGov(app.store, "proposer", 100)
+	voter1 := prepForGov(app.store, "voter1", 200)
+	voter2 := prepForGov(app.store, "voter2", 300)
+	app.store.SetDraft(1, &types.Draft{
+		Proposer:   proposer,
+		Kind:       types.DraftKindText,
+		CloseCount: 10,
+		ApplyCount: 10,
+	})
+	app.store.SetVote(1, voter1, &types.Vote{Option: types.VoteOptionNo})
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	queryjson, _ := json.Marshal(uint32(1))
+	req := abci.RequestQuery{Path: "/draft", Data: queryjson}
+	res := app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+
+	var draftEx types.DraftEx
+	err = json.Unmarshal(res.Value, &draftEx)
+	assert.NoError(t, err)
+	assert.NotNil(t, draftEx.Tally)
+	assert.Equal(t, new(types.Currency).Set(300), &draftEx.Tally.Quorum)
+	assert.Equal(t, new(types.Currency).Set(100), &draftEx.Tally.Approve)
+	assert.Equal(t, new(types.Currency).Set(200), &draftEx.Tally.Reject)
+	assert.True(t, draftEx.Tally.QuorumReached)
+	assert.False(t, draftEx.Tally.Passed)
+	// stored tally untouched
+	assert.Equal(t, types.Zero, &draftEx.TallyApprove)
+
+	app.store.SetVote(1, voter1, &types.Vote{Option: types.VoteOptionYes})
+	_, _, err = app.store.Save()
+	assert.NoError(t, err)
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	err = json.Unmarshal(res.Value, &draftEx)
+	assert.NoError(t, err)
+	assert.True(t, draftEx.Tally.QuorumReached)
+	assert.True(t, draftEx.Tally.Passed)
+
+	// vetoed draft does not pass even with enough approval
+	app.config.DraftPassRate = 0.5
+	app.config.DraftVetoRate = 0.334
+	app.store.SetVote(1, voter2, &types.Vote{Option: types.VoteOptionVeto})
+	_, _, err = app.store.Save()
+	assert.NoError(t, err)
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	err = json.Unmarshal(res.Value, &draftEx)
+	assert.NoError(t, err)
+	assert.True(t, draftEx.Tally.Vetoed)
+	assert.False(t, draftEx.Tally.Passed)
+
+	// no projection out of the vote
+	draft := app.store.GetDraft(1, false)
+	draft.CloseCount = 0
+	app.store.SetDraft(1, draft)
+	_, _, err = app.store.Save()
+	assert.NoError(t, err)
+
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	draftEx = types.DraftEx{}
+	err = json.Unmarshal(res.Value, &draftEx)
+	assert.NoError(t, err)
+	assert.Nil(t, draftEx.Tally)
+}
+
+func TestQueryLockedStake(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.proto = AMOProtocolVersions[0x7]
+
+	validator, _ := ed25519.GenPrivKeyFromSecret([]byte("val")).
+		PubKey().(ed25519.PubKeyEd25519)
+	holder := makeAccAddr("holder")
+	queryjson, _ := json.Marshal(holder)
+
+	app.store.SetUnlockedStake(holder, &types.Stake{
+		Amount:    *new(types.Currency).Set(100),
+		Validator: validator,
+	})
+	app.store.SetLockedStake(holder, &types.Stake{
+		Amount:    *new(types.Currency).Set(200),
+		Validator: validator,
+	}, 3)
+	app.store.SetLockedStake(holder, &types.Stake{
+		Amount:    *new(types.Currency).Set(300),
+		Validator: validator,
+	}, 10)
+
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+	app.state.LastHeight = 5
+
+	var req abci.RequestQuery
+	var res abci.ResponseQuery
+
+	req = abci.RequestQuery{Path: "/stake/unlocked", Data: queryjson}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeBadPath, res.Code)
+
+	req = abci.RequestQuery{Path: "/stake/locked"}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoKey, res.Code)
+
+	req = abci.RequestQuery{Path: "/stake/locked", Data: []byte("f8das")}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeBadKey, res.Code)
+
+	noone, _ := json.Marshal(makeAccAddr("noone"))
+	req = abci.RequestQuery{Path: "/stake/locked", Data: noone}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	req = abci.RequestQuery{Path: "/stake/locked", Data: queryjson}
+	res = app.Query(req)
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, req.Data, res.Key)
+
+	var lockedStakeEx types.LockedStakeEx
+	err = json.Unmarshal(res.Value, &lockedStakeEx)
+	assert.NoError(t, err)
+	assert.Equal(t, tmbytes.HexBytes(validator[:]), lockedStakeEx.Validator)
+	assert.Equal(t, *new(types.Currency).Set(100), lockedStakeEx.Unlocked)
+	assert.Equal(t, *new(types.Currency).Set(500), lockedStakeEx.Locked)
+	assert.Equal(t, []*types.LockedStake{
+		{
+			Amount:       *new(types.Currency).Set(200),
+			Remaining:    3,
+			UnlockHeight: 8,
+		},
+		{
+			Amount:       *new(types.Currency).Set(300),
+			Remaining:    10,
+			UnlockHeight: 15,
+		},
+	}, lockedStakeEx.LockedStakes)
+}
+
 func TestSignedTransactionTest(t *testing.T) {
 	from := p256.GenPrivKeyFromSecret([]byte("alice"))
 
@@ -541,6 +796,239 @@ func TestSignedTransactionTest(t *testing.T) {
 	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{Tx: rawMsg}).Code)
 }
 
+func TestCheckTxParseError(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+
+	app.proto = AMOProtocolVersions[0x6]
+	res := app.CheckTx(abci.RequestCheckTx{Tx: []byte(`{"type":"nosuchtx"}`)})
+	assert.Equal(t, code.TxCodeBadSignature, res.Code)
+
+	app.proto = AMOProtocolVersions[0x7]
+	res = app.CheckTx(abci.RequestCheckTx{Tx: []byte(`{"type":"nosuchtx"}`)})
+	assert.Equal(t, code.TxCodeUnknownTxType, res.Code)
+	res = app.CheckTx(abci.RequestCheckTx{Tx: []byte(`{"type":"transfer","payload":{"amount":"abc"}}`)})
+	assert.Equal(t, code.TxCodeBadField, res.Code)
+	assert.Contains(t, res.Log, "amount")
+	res = app.CheckTx(abci.RequestCheckTx{Tx: []byte(`{"type":"transfer"`)})
+	assert.Equal(t, code.TxCodeBadParam, res.Code)
+}
+
+func TestParseTxStrict(t *testing.T) {
+	parseCode := func(txJson string) (uint32, string) {
+		_, err := AMOProtocolVersions[0x7].ParseTx([]byte(txJson))
+		if err == nil {
+			return code.TxCodeOK, ""
+		}
+		parseErr, ok := err.(*tx.ParseError)
+		if !ok {
+			return code.TxCodeBadParam, ""
+		}
+		return parseErr.Code, parseErr.Field
+	}
+
+	rc, _ := parseCode(`{"type":"transfer","payload":{"to":"218B954DF74E7267E72541CE99AB9F49C410DB96","amount":"100"}}`)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	rc, _ = parseCode(`{"type":"transfer","payload":`)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	rc, _ = parseCode(`{"type":"nosuchtx","payload":{}}`)
+	assert.Equal(t, code.TxCodeUnknownTxType, rc)
+
+	rc, field := parseCode(`{"type":"transfer","memo":"hi","payload":{}}`)
+	assert.Equal(t, code.TxCodeUnknownField, rc)
+	assert.Equal(t, "memo", field)
+
+	rc, field = parseCode(`{"type":"transfer","payload":{"to":"218B954DF74E7267E72541CE99AB9F49C410DB96","amount":"100","memo":"hi"}}`)
+	assert.Equal(t, code.TxCodeUnknownField, rc)
+	assert.Equal(t, "memo", field)
+
+	rc, field = parseCode(`{"type":"transfer","payload":{"to":"218B954DF74E7267E72541CE99AB9F49C410DB96","amount":"abc"}}`)
+	assert.Equal(t, code.TxCodeBadField, rc)
+	assert.Equal(t, "amount", field)
+
+	rc, field = parseCode(`{"type":"propose","payload":{"draft_id":"1"}}`)
+	assert.Equal(t, code.TxCodeBadField, rc)
+	assert.Equal(t, "draft_id", field)
+
+	rc, field = parseCode(`{"type":"propose","payload":[]}`)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	assert.Equal(t, "payload", field)
+
+	// lenient in older protocol versions
+	t1, err := AMOProtocolVersions[0x6].ParseTx([]byte(`{"type":"nosuchtx","memo":"hi","payload":{}}`))
+	assert.NoError(t, err)
+	rc, _ = t1.Check()
+	assert.Equal(t, code.TxCodeUnknown, rc)
+	t1, err = AMOProtocolVersions[0x6].ParseTx([]byte(`{"type":"transfer","payload":{"amount":"abc"}}`))
+	assert.NoError(t, err)
+	rc, _ = t1.Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+}
+
+func TestTxRegistry(t *testing.T) {
+	txTypes := func(version uint64) []string {
+		return AMOProtocolVersions[version].TxRegistry().Types()
+	}
+	classify := func(version uint64, base tx.TxBase) tx.Tx {
+		return AMOProtocolVersions[version].TxRegistry().Classify(base)
+	}
+
+	// inherited from the previous protocol versions
+	assert.Equal(t, len(txTypes(0x4)), len(txTypes(0x5)))
+	assert.Equal(t, len(txTypes(0x5))+4, len(txTypes(0x6)))
+	assert.Equal(t, len(txTypes(0x6))+4, len(txTypes(0x7)))
+	assert.Subset(t, txTypes(0x7), txTypes(0x4))
+	assert.NotContains(t, txTypes(0x6), "rotate_validator")
+	assert.Contains(t, txTypes(0x7), "rotate_validator")
+
+	// replaced in a later protocol version
+	base := tx.TxBase{Type: "transfer", Payload: []byte(`{}`)}
+	assert.IsType(t, &tx.TxTransfer{}, classify(0x4, base))
+	assert.IsType(t, &tx.TxTransferV5{}, classify(0x5, base))
+	assert.IsType(t, &tx.TxTransferV5{}, classify(0x7, base))
+	base.Type = "propose"
+	assert.IsType(t, &tx.TxPropose{}, classify(0x6, base))
+	assert.IsType(t, &tx.TxProposeV7{}, classify(0x7, base))
+
+	// unknown tx type
+	base.Type = "unknown"
+	rc, _ := classify(0x7, base).Check()
+	assert.Equal(t, code.TxCodeUnknown, rc)
+}
+
+func TestCheckTxHighS(t *testing.T) {
+	from := p256.GenPrivKeyFromSecret([]byte("alice"))
+	to := makeTestAddress("bob")
+
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x6
+	app.proto = AMOProtocolVersions[0x6]
+
+	app.store.SetBalance(from.PubKey().Address(), new(types.Currency).Set(1000))
+
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+
+	// (r, n-s) of a low-S signature
+	var base tx.TxBase
+	err = json.Unmarshal(makeTxTransferChain(from, to, 100, ""), &base)
+	assert.NoError(t, err)
+	sig := base.Signature.SigBytes
+	hs := new(big.Int).Sub(elliptic.P256().Params().N,
+		new(big.Int).SetBytes(sig[32:])).Bytes()
+	highS := make([]byte, 64)
+	copy(highS, sig[:32])
+	copy(highS[64-len(hs):], hs)
+	base.Signature.SigBytes = highS
+	rawTx, _ := json.Marshal(base)
+
+	// high-S signature still accepted before v7
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: rawTx}).Code)
+
+	app.state.ProtocolVersion = 0x7
+	app.proto = AMOProtocolVersions[0x7]
+	assert.Equal(t, code.TxCodeBadSignature,
+		app.CheckTx(abci.RequestCheckTx{Tx: rawTx}).Code)
+}
+
+func TestCheckTxState(t *testing.T) {
+	from := p256.GenPrivKeyFromSecret([]byte("alice"))
+	to := makeTestAddress("bob")
+
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x7
+
+	app.store.SetBalance(from.PubKey().Address(), new(types.Currency).Set(1000))
+
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+
+	tx1 := makeTxTransferSeq(from, to, 600, 1)
+	tx2 := makeTxTransferSeq(from, to, 600, 2)
+	tx3 := makeTxTransferSeq(from, to, 400, 2)
+
+	// doomed to fail in DeliverTx
+	res := app.CheckTx(abci.RequestCheckTx{Tx: makeTxTransferSeq(from, to, 2000, 1)})
+	assert.Equal(t, code.TxCodeNotEnoughBalance, res.Code)
+	// the second one not affordable after the first one
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx1}).Code)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, app.CheckTx(abci.RequestCheckTx{Tx: tx2}).Code)
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx3}).Code)
+	// no change in the state
+	assert.Equal(t, new(types.Currency).Set(1000),
+		app.store.GetBalance(from.PubKey().Address(), false))
+
+	// only tx1 gets delivered
+	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{Tx: tx1}).Code)
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+
+	// check state reset to the committed state
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	assert.Equal(t, code.TxCodeNotEnoughBalance, app.CheckTx(abci.RequestCheckTx{Tx: tx2}).Code)
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx3}).Code)
+	// sequence taken by a tx in mempool
+	assert.Equal(t, code.TxCodeBadSequence, app.CheckTx(abci.RequestCheckTx{Tx: tx3}).Code)
+}
+
+func TestCheckTxUnlockEarly(t *testing.T) {
+	priv := p256.GenPrivKeyFromSecret([]byte("alice"))
+	staker := priv.PubKey().Address()
+	val, _ := ed25519.GenPrivKeyFromSecret([]byte("val")).PubKey().(ed25519.PubKeyEd25519)
+
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x7
+	app.proto = AMOProtocolVersions[0x7]
+	app.config.BlkReward = *new(types.Currency).Set(0)
+	app.config.TxReward = *new(types.Currency).Set(0)
+	b, err := json.Marshal(app.config)
+	assert.NoError(t, err)
+	assert.NoError(t, app.store.SetAppConfig(b))
+
+	app.store.SetUnlockedStake(staker, &types.Stake{
+		Amount:    *new(types.Currency).Set(1000),
+		Validator: val,
+	})
+	app.store.SetLockedStake(staker, &types.Stake{
+		Amount:    *new(types.Currency).Set(1000),
+		Validator: val,
+	}, 10)
+
+	// immitate initChain() function call
+	_, _, err = app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+
+	// tranche unlocked at height 10 when locked at height 1
+	payload, _ := json.Marshal(tx.UnlockEarlyParam{UnlockHeight: 10})
+	_tx := tx.TxBase{
+		Type:       "unlock_early",
+		Payload:    payload,
+		Sender:     staker,
+		Fee:        *new(types.Currency).Set(0),
+		LastHeight: "1",
+	}
+	_tx.Sign(priv)
+	rawTx, _ := json.Marshal(_tx)
+
+	// checked between blocks as it would be executed in the next block
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: rawTx}).Code)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{Tx: rawTx}).Code)
+	assert.Nil(t, app.store.GetLockedStake(staker, 9, false))
+}
+
 func TestFuncValUpdates(t *testing.T) {
 	val1 := abci.ValidatorUpdate{
 		PubKey: abci.PubKey{Type: "anything", Data: []byte("0001")},
@@ -643,6 +1131,79 @@ func TestPenaltyEvidence(t *testing.T) {
 	assert.Equal(t, ces, aes)
 }
 
+func TestPenaltyEvidenceReporter(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x4
+	app.config.BlkReward = *new(types.Currency).Set(0)
+	app.config.TxReward = *new(types.Currency).Set(0)
+	app.config.PenaltyRatioM = 0.1
+	app.config.PenaltyRatios = map[string]float64{"mock/evidence": 0.2}
+	app.config.MaxEvidenceAge = 10
+	app.config.PenaltyReporterRate = 0.5
+	app.config.PenaltyToPool = true
+
+	// setup
+	val1, _ := ed25519.GenPrivKeyFromSecret([]byte("val1")).PubKey().(ed25519.PubKeyEd25519)
+	staker1 := p256.GenPrivKeyFromSecret([]byte("staker1")).PubKey().Address()
+	app.store.SetUnlockedStake(staker1, &types.Stake{
+		Amount:    *new(types.Currency).Set(1000),
+		Validator: val1,
+	})
+	val2, _ := ed25519.GenPrivKeyFromSecret([]byte("val2")).PubKey().(ed25519.PubKeyEd25519)
+	staker2 := p256.GenPrivKeyFromSecret([]byte("staker2")).PubKey().Address()
+	app.store.SetUnlockedStake(staker2, &types.Stake{
+		Amount:    *new(types.Currency).Set(1000),
+		Validator: val2,
+	})
+	val3, _ := ed25519.GenPrivKeyFromSecret([]byte("val3")).PubKey().(ed25519.PubKeyEd25519)
+	proposer := p256.GenPrivKeyFromSecret([]byte("proposer")).PubKey().Address()
+	app.store.SetUnlockedStake(proposer, &types.Stake{
+		Amount:    *new(types.Currency).Set(1000),
+		Validator: val3,
+	})
+	app.store.Save()
+
+	evidences := []abci.Evidence{
+		{
+			Type:      "duplicate/vote",
+			Validator: abci.Validator{Address: val1.Address()},
+			Height:    int64(15),
+		},
+		{
+			Type:      "mock/evidence",
+			Validator: abci.Validator{Address: val2.Address()},
+			Height:    int64(16),
+		},
+		// too old
+		{
+			Type:      "duplicate/vote",
+			Validator: abci.Validator{Address: val3.Address()},
+			Height:    int64(5),
+		},
+	}
+
+	app.BeginBlock(abci.RequestBeginBlock{
+		Header: abci.Header{
+			Height:          20,
+			ProposerAddress: val3.Address(),
+		},
+		ByzantineValidators: evidences,
+	})
+	app.EndBlock(abci.RequestEndBlock{})
+
+	assert.Equal(t, *new(types.Currency).Set(900),
+		app.store.GetStake(staker1, false).Amount)
+	assert.Equal(t, *new(types.Currency).Set(800),
+		app.store.GetStake(staker2, false).Amount)
+	assert.Equal(t, *new(types.Currency).Set(1000),
+		app.store.GetStake(proposer, false).Amount)
+	// reporter reward: (100 + 200) * 0.5
+	assert.Equal(t, new(types.Currency).Set(150),
+		app.store.GetBalance(proposer, false))
+	assert.Equal(t, new(types.Currency).Set(150),
+		app.store.GetCommunityPool(false))
+}
+
 func TestPenaltyLazyValidators(t *testing.T) {
 	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
 	app.state.ProtocolVersion = 0x4
@@ -813,6 +1374,115 @@ func TestEndBlock(t *testing.T) {
 	assert.Equal(t, code.TxCodeOK, resDeliver.Code)
 }
 
+func TestEndBlockRotateValidator(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x7
+
+	// setup
+	tx.ConfigAMOApp.LockupPeriod = 1                               // manipulate
+	tx.ConfigAMOApp.MinStakingUnit = *new(types.Currency).Set(100) // manipulate
+	priv1 := p256.GenPrivKeyFromSecret([]byte("staker1"))
+	app.store.SetBalance(priv1.PubKey().Address(), new(types.Currency).Set(500))
+
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	rawTx := makeTxStake(priv1, "val1", 100, "1")
+	resDeliver := app.DeliverTx(abci.RequestDeliverTx{Tx: rawTx})
+	assert.Equal(t, code.TxCodeOK, resDeliver.Code)
+	validators := app.EndBlock(abci.RequestEndBlock{Height: 1}).ValidatorUpdates
+	assert.Equal(t, 1, len(validators))
+	app.Commit()
+
+	// rotate validator key
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	rawTx = makeTxRotateValidator(priv1, "val2")
+	resDeliver = app.DeliverTx(abci.RequestDeliverTx{Tx: rawTx})
+	assert.Equal(t, code.TxCodeOK, resDeliver.Code)
+	validators = app.EndBlock(abci.RequestEndBlock{Height: 2}).ValidatorUpdates
+	assert.Equal(t, 2, len(validators))
+
+	val1, _ := ed25519.GenPrivKeyFromSecret([]byte("val1")).
+		PubKey().(ed25519.PubKeyEd25519)
+	val2, _ := ed25519.GenPrivKeyFromSecret([]byte("val2")).
+		PubKey().(ed25519.PubKeyEd25519)
+	for _, v := range validators {
+		if bytes.Equal(v.PubKey.Data, val1[:]) {
+			assert.Equal(t, int64(0), v.Power)
+		} else {
+			assert.Equal(t, val2[:], v.PubKey.Data)
+			assert.Equal(t, int64(100), v.Power)
+		}
+	}
+}
+
+func TestEndBlockIneligibleValidator(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x7
+
+	// setup
+	tx.ConfigAMOApp.LockupPeriod = 1                               // manipulate
+	tx.ConfigAMOApp.MinStakingUnit = *new(types.Currency).Set(100) // manipulate
+	priv1 := p256.GenPrivKeyFromSecret([]byte("staker1"))
+	app.store.SetBalance(priv1.PubKey().Address(), new(types.Currency).Set(500))
+	priv2 := p256.GenPrivKeyFromSecret([]byte("staker2"))
+	app.store.SetBalance(priv2.PubKey().Address(), new(types.Currency).Set(500))
+
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	rawTx := makeTxStake(priv1, "val1", 100, "1")
+	resDeliver := app.DeliverTx(abci.RequestDeliverTx{Tx: rawTx})
+	assert.Equal(t, code.TxCodeOK, resDeliver.Code)
+	rawTx = makeTxStake(priv2, "val2", 200, "1")
+	resDeliver = app.DeliverTx(abci.RequestDeliverTx{Tx: rawTx})
+	assert.Equal(t, code.TxCodeOK, resDeliver.Code)
+	validators := app.EndBlock(abci.RequestEndBlock{Height: 1}).ValidatorUpdates
+	assert.Equal(t, 2, len(validators))
+	app.Commit()
+
+	// raise minimum self-stake as if a draft got applied
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	cfg := app.config
+	cfg.MinSelfStake = *new(types.Currency).Set(150)
+	b, err := json.Marshal(cfg)
+	assert.NoError(t, err)
+	app.store.SetAppConfig(b)
+
+	// validator set under the new config gets reported at once
+	res := app.EndBlock(abci.RequestEndBlock{Height: 2})
+	val1, _ := ed25519.GenPrivKeyFromSecret([]byte("val1")).
+		PubKey().(ed25519.PubKeyEd25519)
+	assert.Equal(t, 2, len(res.ValidatorUpdates))
+	for _, v := range res.ValidatorUpdates {
+		if bytes.Equal(v.PubKey.Data, val1[:]) {
+			assert.Equal(t, int64(0), v.Power)
+		} else {
+			assert.Equal(t, int64(200), v.Power)
+		}
+	}
+	found := false
+	for _, ev := range res.Events {
+		if ev.Type != "validator_ineligible" {
+			continue
+		}
+		found = true
+		addressJson, _ := json.Marshal(priv1.PubKey().Address())
+		assert.Equal(t, addressJson, ev.Attributes[1].Value)
+	}
+	assert.True(t, found)
+	app.Commit()
+
+	// no more updates
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 3}})
+	res = app.EndBlock(abci.RequestEndBlock{Height: 3})
+	assert.Equal(t, 0, len(res.ValidatorUpdates))
+}
+
 func DivCurrency(origin *types.Currency, divisor *types.Currency) *types.Currency {
 	return new(types.Currency).Set(origin.Div(&origin.Int, &divisor.Int).Uint64())
 }
@@ -854,11 +1524,11 @@ func TestIncentive(t *testing.T) {
 		},
 	})
 
-	rawTx := makeTxDelegate(d1Priv, sPriv.PubKey().Address(), 100)
+	rawTx := makeTxDelegate(d1Priv, sPriv.PubKey().Address(), 100, "1")
 	resDeliver := app.DeliverTx(abci.RequestDeliverTx{Tx: rawTx})
 	assert.Equal(t, code.TxCodeOK, resDeliver.Code)
 
-	rawTx = makeTxDelegate(d2Priv, sPriv.PubKey().Address(), 200)
+	rawTx = makeTxDelegate(d2Priv, sPriv.PubKey().Address(), 200, "1")
 	resDeliver = app.DeliverTx(abci.RequestDeliverTx{Tx: rawTx})
 	assert.Equal(t, code.TxCodeOK, resDeliver.Code)
 
@@ -893,11 +1563,11 @@ func TestIncentive(t *testing.T) {
 		},
 	})
 
-	rawTx = makeTxDelegate(d1Priv, sPriv.PubKey().Address(), 100)
+	rawTx = makeTxDelegate(d1Priv, sPriv.PubKey().Address(), 100, "2")
 	resDeliver = app.DeliverTx(abci.RequestDeliverTx{Tx: rawTx})
 	assert.Equal(t, code.TxCodeOK, resDeliver.Code)
 
-	rawTx = makeTxDelegate(d2Priv, sPriv.PubKey().Address(), 200)
+	rawTx = makeTxDelegate(d2Priv, sPriv.PubKey().Address(), 200, "2")
 	resDeliver = app.DeliverTx(abci.RequestDeliverTx{Tx: rawTx})
 	assert.Equal(t, code.TxCodeOK, resDeliver.Code)
 
@@ -1049,8 +1719,11 @@ func TestEmptyBlock(t *testing.T) {
 func TestReplayAttack(t *testing.T) {
 	t1 := p256.GenPrivKeyFromSecret([]byte("test1"))
 	tx1 := makeTxStake(t1, "test1", 10000, "1")
-	tx2 := makeTxStake(t1, "test1", 10000, "1")
-	tx3 := makeTxStake(t1, "test1", 10000, "1")
+	tx2 := makeTxStake(t1, "test1", 10100, "1")
+	tx3 := makeTxStake(t1, "test1", 10200, "1")
+
+	// signing is deterministic, so no way to get a different tx by re-signing
+	assert.Equal(t, tx1, makeTxStake(t1, "test1", 10000, "1"))
 
 	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
 	app.state.ProtocolVersion = 0x4
@@ -1065,6 +1738,10 @@ func TestReplayAttack(t *testing.T) {
 
 	app.store.SetBalance(t1.PubKey().Address(), new(types.Currency).Set(40000))
 
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
 	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
 
 	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx1}).Code)
@@ -1109,8 +1786,8 @@ func TestBindingBlock(t *testing.T) {
 	tx1 := makeTxStake(t1, "test1", 10000, "1")
 	tx2 := makeTxStake(t1, "test1", 10000, "2")
 	tx3 := makeTxStake(t1, "test1", 10000, "3")
-	tx4 := makeTxStake(t1, "test1", 10000, "1")
-	tx5 := makeTxStake(t1, "test1", 10000, "2")
+	tx4 := makeTxStake(t1, "test1", 10100, "1")
+	tx5 := makeTxStake(t1, "test1", 10100, "2")
 
 	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
 	app.state.ProtocolVersion = 0x4
@@ -1125,6 +1802,10 @@ func TestBindingBlock(t *testing.T) {
 
 	app.store.SetBalance(t1.PubKey().Address(), new(types.Currency).Set(50000))
 
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
 	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
 
 	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx1}).Code)
@@ -1157,6 +1838,325 @@ func TestBindingBlock(t *testing.T) {
 	app.EndBlock(abci.RequestEndBlock{Height: 4})
 }
 
+func TestSequence(t *testing.T) {
+	t1 := p256.GenPrivKeyFromSecret([]byte("test1"))
+	to := makeTestAddress("test2")
+	tx1 := makeTxTransferSeq(t1, to, 100, 1)
+	tx2 := makeTxTransferSeq(t1, to, 100, 2)
+	tx3 := makeTxTransferSeq(t1, to, 100, 3)
+	// transfer more than balance
+	tx4 := makeTxTransferSeq(t1, to, 100000, 4)
+
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x7
+	app.proto = AMOProtocolVersions[0x7]
+	app.config.BlockBindingWindow = int64(3)
+	app.replayPreventer = blockchain.NewReplayPreventer(
+		app.store,
+		app.state.LastHeight,
+		app.config.BlockBindingWindow,
+	)
+
+	app.store.SetBalance(t1.PubKey().Address(), new(types.Currency).Set(1000))
+
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+
+	// sequence in mempool has no gap
+	assert.Equal(t, code.TxCodeBadSequence, app.CheckTx(abci.RequestCheckTx{Tx: tx2}).Code)
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx1}).Code)
+	assert.Equal(t, code.TxCodeBadSequence, app.CheckTx(abci.RequestCheckTx{Tx: tx1}).Code)
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx2}).Code)
+	assert.Equal(t, code.TxCodeBadSequence, app.DeliverTx(abci.RequestDeliverTx{Tx: tx2}).Code)
+
+	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{Tx: tx1}).Code)
+	assert.Equal(t, code.TxCodeBadSequence, app.DeliverTx(abci.RequestDeliverTx{Tx: tx1}).Code)
+	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{Tx: tx2}).Code)
+
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+
+	// long after the block binding window
+	for h := int64(2); h < 10; h++ {
+		app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: h}})
+		app.EndBlock(abci.RequestEndBlock{Height: h})
+		app.Commit()
+	}
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 10}})
+
+	assert.Equal(t, code.TxCodeBadSequence, app.CheckTx(abci.RequestCheckTx{Tx: tx1}).Code)
+	assert.Equal(t, code.TxCodeBadSequence, app.CheckTx(abci.RequestCheckTx{Tx: tx2}).Code)
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx3}).Code)
+	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{Tx: tx3}).Code)
+
+	// sequence is consumed by a failed tx
+	assert.Equal(t, code.TxCodeNotEnoughBalance, app.DeliverTx(abci.RequestDeliverTx{Tx: tx4}).Code)
+	assert.Equal(t, uint64(4), app.store.GetSequence(t1.PubKey().Address(), false))
+
+	app.EndBlock(abci.RequestEndBlock{Height: 10})
+	app.Commit()
+
+	addrJson, _ := json.Marshal(t1.PubKey().Address())
+	res := app.Query(abci.RequestQuery{Path: "/sequence", Data: addrJson})
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, []byte("4"), res.Value)
+}
+
+func TestSequenceBeforeV7(t *testing.T) {
+	t1 := p256.GenPrivKeyFromSecret([]byte("test1"))
+	to := makeTestAddress("test2")
+	tx1 := makeTxTransferSeq(t1, to, 100, 1)
+
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x6
+	app.proto = AMOProtocolVersions[0x6]
+	app.config.BlockBindingWindow = int64(3)
+	app.replayPreventer = blockchain.NewReplayPreventer(
+		app.store,
+		app.state.LastHeight,
+		app.config.BlockBindingWindow,
+	)
+
+	app.store.SetBalance(t1.PubKey().Address(), new(types.Currency).Set(1000))
+
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+
+	// sequence is not a part of tx signed before v7
+	assert.Equal(t, code.TxCodeBadSignature, app.CheckTx(abci.RequestCheckTx{Tx: tx1}).Code)
+
+	// tx with a sequence still goes through the replay preventer
+	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{Tx: tx1}).Code)
+	assert.Equal(t, code.TxCodeImproperTx, app.DeliverTx(abci.RequestDeliverTx{Tx: tx1}).Code)
+	assert.Equal(t, uint64(0), app.store.GetSequence(t1.PubKey().Address(), false))
+
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+}
+
+func TestChainID(t *testing.T) {
+	t1 := p256.GenPrivKeyFromSecret([]byte("test1"))
+	to := makeTestAddress("test2")
+
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.InitChain(abci.RequestInitChain{
+		ChainId:       "amo-test",
+		AppStateBytes: []byte(`{ "state": { "protocol_version": 7 } }`),
+	})
+	assert.Equal(t, "amo-test", app.store.GetChainID(true))
+
+	app.store.SetBalance(t1.PubKey().Address(), new(types.Currency).Set(1000))
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{
+		ChainID: "amo-test",
+		Height:  1,
+	}})
+
+	tx1 := makeTxTransferChain(t1, to, 100, "amo-test")
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx1}).Code)
+	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{Tx: tx1}).Code)
+
+	// signed for another chain, or for no chain
+	tx2 := makeTxTransferChain(t1, to, 100, "amo-other")
+	assert.Equal(t, code.TxCodeBadChainID, app.CheckTx(abci.RequestCheckTx{Tx: tx2}).Code)
+	assert.Equal(t, code.TxCodeBadChainID, app.DeliverTx(abci.RequestDeliverTx{Tx: tx2}).Code)
+	tx3 := makeTxTransferChain(t1, to, 100, "")
+	assert.Equal(t, code.TxCodeBadChainID, app.CheckTx(abci.RequestCheckTx{Tx: tx3}).Code)
+
+	// chain id is not a part of tx before protocol v7
+	app.proto = AMOProtocolVersions[0x6]
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx3}).Code)
+	assert.Equal(t, code.TxCodeBadSignature, app.CheckTx(abci.RequestCheckTx{Tx: tx2}).Code)
+}
+
+func TestMinFee(t *testing.T) {
+	t1 := p256.GenPrivKeyFromSecret([]byte("test1"))
+	to := makeTestAddress("test2")
+
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x7
+	app.config.MinFees = map[string]types.FeeRate{
+		"transfer": {
+			Base:    *new(types.Currency).Set(100),
+			PerByte: *new(types.Currency).Set(1),
+		},
+	}
+
+	app.store.SetBalance(t1.PubKey().Address(), new(types.Currency).Set(10000))
+
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+
+	param, _ := json.Marshal(tx.TransferParam{
+		To:     to,
+		Amount: *new(types.Currency).Set(100),
+	})
+	minFee := uint64(100 + len(param))
+	makeTx := func(fee uint64) []byte {
+		_tx := tx.TxBase{
+			Type:       "transfer",
+			Payload:    param,
+			Sender:     t1.PubKey().Address(),
+			Fee:        *new(types.Currency).Set(fee),
+			LastHeight: "1",
+		}
+		_tx.Sign(t1)
+		rawTx, _ := json.Marshal(_tx)
+		return rawTx
+	}
+
+	tx1 := makeTx(0)
+	assert.Equal(t, code.TxCodeInsufficientFee, app.CheckTx(abci.RequestCheckTx{Tx: tx1}).Code)
+	assert.Equal(t, code.TxCodeInsufficientFee, app.DeliverTx(abci.RequestDeliverTx{Tx: tx1}).Code)
+	tx2 := makeTx(minFee - 1)
+	assert.Equal(t, code.TxCodeInsufficientFee, app.CheckTx(abci.RequestCheckTx{Tx: tx2}).Code)
+	tx3 := makeTx(minFee)
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{Tx: tx3}).Code)
+	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{Tx: tx3}).Code)
+
+	// no minimum fee for other tx types
+	assert.NotEqual(t, code.TxCodeInsufficientFee, app.CheckTx(abci.RequestCheckTx{
+		Tx: makeTxStake(t1, "val1", 0, "1")}).Code)
+
+	res := app.Query(abci.RequestQuery{Path: "/fee"})
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, `{"transfer":{"base":"100","per_byte":"1","per_cost":"0"}}`, string(res.Value))
+
+	app.config.MinFees = nil
+	res = app.Query(abci.RequestQuery{Path: "/fee"})
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+	assert.Equal(t, `{}`, string(res.Value))
+}
+
+func TestTxCost(t *testing.T) {
+	t1 := p256.GenPrivKeyFromSecret([]byte("test1"))
+	to := makeTestAddress("test2")
+
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x7
+
+	app.store.SetBalance(t1.PubKey().Address(), new(types.Currency).Set(100000))
+
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+
+	makeTx := func(amount, fee uint64) []byte {
+		param, _ := json.Marshal(tx.TransferParam{
+			To:     to,
+			Amount: *new(types.Currency).Set(amount),
+		})
+		_tx := tx.TxBase{
+			Type:       "transfer",
+			Payload:    param,
+			Sender:     t1.PubKey().Address(),
+			Fee:        *new(types.Currency).Set(fee),
+			LastHeight: "1",
+		}
+		_tx.Sign(t1)
+		rawTx, _ := json.Marshal(_tx)
+		return rawTx
+	}
+
+	// cost is reported even without any fee or limit
+	res := app.DeliverTx(abci.RequestDeliverTx{Tx: makeTx(100, 0)})
+	assert.Equal(t, code.TxCodeOK, res.Code)
+	cost := uint64(res.GasUsed)
+	assert.True(t, cost > 0)
+	assert.Equal(t, cost, app.blockCost)
+
+	// fee per cost unit
+	app.config.MinFees = map[string]types.FeeRate{
+		"transfer": {PerCost: *new(types.Currency).Set(1)},
+	}
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: makeTx(101, cost-1)})
+	assert.Equal(t, code.TxCodeInsufficientFee, res.Code)
+	assert.Equal(t, new(types.Currency).Set(100),
+		app.store.GetBalance(to, false))
+	// fee is charged and execution cost counted for the failed tx
+	assert.Equal(t, new(types.Currency).Set(100000-100-cost+1),
+		app.store.GetBalance(t1.PubKey().Address(), false))
+	assert.Equal(t, new(types.Currency).Set(cost-1), &app.feeAccumulated)
+	assert.Equal(t, cost+uint64(res.GasUsed), app.blockCost)
+	assert.Equal(t, code.TxCodeInsufficientFee,
+		app.CheckTx(abci.RequestCheckTx{Tx: makeTx(102, 0)}).Code)
+	res = app.DeliverTx(abci.RequestDeliverTx{Tx: makeTx(103, 2*cost)})
+	assert.Equal(t, code.TxCodeOK, res.Code)
+	assert.Equal(t, new(types.Currency).Set(203),
+		app.store.GetBalance(to, false))
+	app.config.MinFees = nil
+
+	// block cost limit
+	app.config.MaxBlockCost = app.blockCost + 1
+	assert.Equal(t, code.TxCodeExceededBlockCost,
+		app.DeliverTx(abci.RequestDeliverTx{Tx: makeTx(104, 0)}).Code)
+	assert.Equal(t, new(types.Currency).Set(203),
+		app.store.GetBalance(to, false))
+
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	assert.Equal(t, uint64(0), app.blockCost)
+	assert.Equal(t, code.TxCodeOK,
+		app.DeliverTx(abci.RequestDeliverTx{Tx: makeTx(105, 0)}).Code)
+
+	// tx costing more than a block can take never enters mempool
+	app.config.MaxBlockCost = 1
+	assert.Equal(t, code.TxCodeExceededBlockCost,
+		app.CheckTx(abci.RequestCheckTx{Tx: makeTx(106, 0)}).Code)
+}
+
+func TestTxCostBeforeV7(t *testing.T) {
+	t1 := p256.GenPrivKeyFromSecret([]byte("test1"))
+	to := makeTestAddress("test2")
+
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x6
+	app.proto = AMOProtocolVersions[0x6]
+
+	app.store.SetBalance(t1.PubKey().Address(), new(types.Currency).Set(1000))
+
+	// immitate initChain() function call
+	_, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+
+	// no metering nor limit
+	app.config.MaxBlockCost = 1
+	res := app.DeliverTx(abci.RequestDeliverTx{
+		Tx: makeTxTransferChain(t1, to, 100, "")})
+	assert.Equal(t, code.TxCodeOK, res.Code)
+	assert.Equal(t, int64(0), res.GasUsed)
+	assert.Equal(t, uint64(0), app.blockCost)
+	assert.Equal(t, new(types.Currency).Set(100),
+		app.store.GetBalance(to, false))
+
+	// no minimum fee either
+	app.config.MinFees = map[string]types.FeeRate{
+		"transfer": {Base: *new(types.Currency).Set(100)},
+	}
+	assert.Equal(t, code.TxCodeOK, app.CheckTx(abci.RequestCheckTx{
+		Tx: makeTxTransferChain(t1, to, 101, "")}).Code)
+	assert.Equal(t, code.TxCodeOK, app.DeliverTx(abci.RequestDeliverTx{
+		Tx: makeTxTransferChain(t1, to, 101, "")}).Code)
+	assert.Equal(t, new(types.Currency).Set(201),
+		app.store.GetBalance(to, false))
+}
+
 func TestGovernance(t *testing.T) {
 	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
 	app.state.ProtocolVersion = 0x4
@@ -1414,7 +2414,63 @@ func makeTxStake(priv p256.PrivKeyP256, val string, amount uint64, lastHeight st
 	return rawTx
 }
 
-func makeTxDelegate(priv p256.PrivKeyP256, to crypto.Address, amount uint64) []byte {
+func makeTxTransferSeq(priv p256.PrivKeyP256, to crypto.Address, amount uint64, seq uint64) []byte {
+	param := tx.TransferParam{
+		To:     to,
+		Amount: *new(types.Currency).Set(amount),
+	}
+	payload, _ := json.Marshal(param)
+	_tx := tx.TxBase{
+		Type:     "transfer",
+		Payload:  payload,
+		Sender:   priv.PubKey().Address(),
+		Fee:      *new(types.Currency).Set(0),
+		Sequence: seq,
+	}
+	_tx.Sign(priv)
+	rawTx, _ := json.Marshal(_tx)
+	return rawTx
+}
+
+func makeTxTransferChain(priv p256.PrivKeyP256, to crypto.Address, amount uint64, chainID string) []byte {
+	param := tx.TransferParam{
+		To:     to,
+		Amount: *new(types.Currency).Set(amount),
+	}
+	payload, _ := json.Marshal(param)
+	_tx := tx.TxBase{
+		Type:       "transfer",
+		Payload:    payload,
+		Sender:     priv.PubKey().Address(),
+		Fee:        *new(types.Currency).Set(0),
+		LastHeight: "1",
+		ChainID:    chainID,
+	}
+	_tx.Sign(priv)
+	rawTx, _ := json.Marshal(_tx)
+	return rawTx
+}
+
+func makeTxRotateValidator(priv p256.PrivKeyP256, val string) []byte {
+	validator, _ := ed25519.GenPrivKeyFromSecret([]byte(val)).
+		PubKey().(ed25519.PubKeyEd25519)
+	param := tx.RotateValidatorParam{
+		Validator: validator[:],
+	}
+	payload, _ := json.Marshal(param)
+	_tx := tx.TxBase{
+		Type:       "rotate_validator",
+		Payload:    payload,
+		Sender:     priv.PubKey().Address(),
+		Fee:        *new(types.Currency).Set(0),
+		LastHeight: "1",
+	}
+	_tx.Sign(priv)
+	rawTx, _ := json.Marshal(_tx)
+	return rawTx
+}
+
+func makeTxDelegate(priv p256.PrivKeyP256, to crypto.Address, amount uint64, lastHeight string) []byte {
 	param := tx.DelegateParam{
 		To:     to,
 		Amount: *new(types.Currency).Set(amount),
@@ -1425,7 +2481,7 @@ func makeTxDelegate(priv p256.PrivKeyP256, to crypto.Address, amount uint64) []b
 		Payload:    payload,
 		Sender:     priv.PubKey().Address(),
 		Fee:        *new(types.Currency).Set(0),
-		LastHeight: "1",
+		LastHeight: lastHeight,
 	}
 	_tx.Sign(priv)
 	rawTx, _ := json.Marshal(_tx)
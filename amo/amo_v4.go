@@ -1,6 +1,8 @@
 package amo
 
 import (
+	abci "github.com/tendermint/tendermint/abci/types"
+
 	"github.com/amolabs/amoabci/amo/tx"
 )
 
@@ -15,5 +17,103 @@ func (proto *AMOProtocolV4) Version() uint64 {
 }
 
 func (proto *AMOProtocolV4) ParseTx(txBytes []byte) (tx.Tx, error) {
-	return tx.ParseTx(txBytes)
+	return txRegistryV4.Parse(txBytes)
+}
+
+func (proto *AMOProtocolV4) TxRegistry() tx.TxRegistry {
+	return txRegistryV4
+}
+
+func (proto *AMOProtocolV4) QueryRoutes() QueryRoutes {
+	return queryRoutesV4
+}
+
+func (proto *AMOProtocolV4) Migration() *ProtocolMigration {
+	return nil
+}
+
+var txRegistryV4 = tx.TxRegistry{
+	"transfer": tx.NewTxTransfer,
+	"stake":    tx.NewTxStake,
+	"withdraw": tx.NewTxWithdraw,
+	"delegate": tx.NewTxDelegate,
+	"retract":  tx.NewTxRetract,
+	"setup":    tx.NewTxSetup,
+	"close":    tx.NewTxClose,
+	"register": tx.NewTxRegister,
+	"discard":  tx.NewTxDiscard,
+	"request":  tx.NewTxRequest,
+	"cancel":   tx.NewTxCancel,
+	"grant":    tx.NewTxGrant,
+	"revoke":   tx.NewTxRevoke,
+	"claim":    tx.NewTxClaim,
+	"dismiss":  tx.NewTxDismiss,
+	"issue":    tx.NewTxIssue,
+	"propose":  tx.NewTxPropose,
+	"vote":     tx.NewTxVote,
+	"lock":     tx.NewTxLock,
+	"burn":     tx.NewTxBurn,
+}
+
+var queryRoutesV4 = QueryRoutes{
+	"version": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryVersion(app)
+	},
+	"config": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryAppConfig(app.config)
+	},
+	"txtypes": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryTxTypes(app)
+	},
+	"balance": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		if len(args) == 0 {
+			return queryBalance(app.store, "", data)
+		}
+		return queryBalance(app.store, args[0], data)
+	},
+	"udc": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryUDC(app.store, data)
+	},
+	"udclock": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		if len(args) != 1 {
+			return badPath()
+		}
+		return queryUDCLock(app.store, args[0], data)
+	},
+	"stake": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryStake(app.store, data)
+	},
+	"delegate": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryDelegate(app.store, data)
+	},
+	"validator": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryValidator(app.store, data)
+	},
+	"hibernate": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryHibernate(app.store, data)
+	},
+	"storage": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryStorage(app.store, data)
+	},
+	"draft": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryDraft(app.store, nil, data)
+	},
+	"vote": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryVote(app.store, data)
+	},
+	"parcel": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryParcel(app.store, data)
+	},
+	"request": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryRequest(app.store, data)
+	},
+	"usage": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryUsage(app.store, data)
+	},
+	"did": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryDIDEntry(app.store, data)
+	},
+	"vc": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryVCEntry(app.store, data)
+	},
 }
@@ -15,5 +15,13 @@ func (proto *AMOProtocolV5) Version() uint64 {
 }
 
 func (proto *AMOProtocolV5) ParseTx(txBytes []byte) (tx.Tx, error) {
-	return tx.ParseTxV5(txBytes)
+	return txRegistryV5.Parse(txBytes)
 }
+
+func (proto *AMOProtocolV5) TxRegistry() tx.TxRegistry {
+	return txRegistryV5
+}
+
+var txRegistryV5 = txRegistryV4.Extend(tx.TxRegistry{
+	"transfer": tx.NewTxTransferV5,
+})
@@ -15,5 +15,16 @@ func (proto *AMOProtocolV6) Version() uint64 {
 }
 
 func (proto *AMOProtocolV6) ParseTx(txBytes []byte) (tx.Tx, error) {
-	return tx.ParseTxV6(txBytes)
+	return txRegistryV6.Parse(txBytes)
 }
+
+func (proto *AMOProtocolV6) TxRegistry() tx.TxRegistry {
+	return txRegistryV6
+}
+
+var txRegistryV6 = txRegistryV5.Extend(tx.TxRegistry{
+	"did.claim":   tx.NewTxDIDClaim,
+	"did.dismiss": tx.NewTxDIDDismiss,
+	"did.issue":   tx.NewTxDIDIssue,
+	"did.revoke":  tx.NewTxDIDRevoke,
+})
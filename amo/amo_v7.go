@@ -0,0 +1,98 @@
+package amo
+
+import (
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+
+	astore "github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/tx"
+)
+
+var _ AMOProtocol = (*AMOProtocolV7)(nil)
+
+type AMOProtocolV7 struct {
+	AMOProtocolV6
+}
+
+func (proto *AMOProtocolV7) Version() uint64 {
+	return 0x7
+}
+
+func (proto *AMOProtocolV7) ParseTx(txBytes []byte) (tx.Tx, error) {
+	return txRegistryV7.ParseStrict(txBytes)
+}
+
+func (proto *AMOProtocolV7) TxRegistry() tx.TxRegistry {
+	return txRegistryV7
+}
+
+func (proto *AMOProtocolV7) QueryRoutes() QueryRoutes {
+	return queryRoutesV7
+}
+
+func (proto *AMOProtocolV7) Migration() *ProtocolMigration {
+	return &migrationV7
+}
+
+var txRegistryV7 = txRegistryV6.Extend(tx.TxRegistry{
+	"propose":          tx.NewTxProposeV7,
+	"vote":             tx.NewTxVoteV7,
+	"rotate_validator": tx.NewTxRotateValidator,
+	"unlock_early":     tx.NewTxUnlockEarly,
+	"deposit_draft":    tx.NewTxDepositDraft,
+	"withdraw_draft":   tx.NewTxWithdrawDraft,
+})
+
+var migrationV7 = ProtocolMigration{
+	Changes: []string{
+		"record chain id which txs get bound to",
+		"list the latest draft as active if in process",
+	},
+	Operation: func(s *astore.Store, st State, logger log.Logger) error {
+		if len(s.GetChainID(false)) == 0 {
+			err := s.SetChainID(st.ChainID)
+			if err != nil {
+				return err
+			}
+		}
+		// the latest draft is the only one possibly in process so far
+		draftID := st.NextDraftID - uint32(1)
+		draft := s.GetDraft(draftID, false)
+		if draft != nil && (draft.OpenCount > 0 ||
+			draft.CloseCount > 0 || draft.ApplyCount > 0) {
+			s.AddActiveDraft(draftID)
+		}
+		return nil
+	},
+}
+
+var queryRoutesV7 = queryRoutesV4.extend(QueryRoutes{
+	"stake": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		if len(args) == 0 {
+			return queryStake(app.store, data)
+		}
+		if args[0] != "locked" {
+			return badPath()
+		}
+		return queryLockedStake(app.store, app.state.LastHeight, data)
+	},
+	"validators": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryValidators(app.store, app.config)
+	},
+	"pool": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryCommunityPool(app.store)
+	},
+	"upgrade": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryUpgradePlan(app.store)
+	},
+	"sequence": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return querySequence(app.store, data)
+	},
+	"fee": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryFee(&app.config)
+	},
+	// projected tally while the vote is open
+	"draft": func(app *AMOApp, args []string, data []byte) abci.ResponseQuery {
+		return queryDraft(app.store, &app.config, data)
+	},
+})
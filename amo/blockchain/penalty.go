@@ -19,15 +19,27 @@ import (
 // - Malicious Validator: M
 // - Lazy Validator: L
 
+// PenaltyParams tells which evidences count, how much they cost and where
+// slashed stakes go.
+type PenaltyParams struct {
+	Ratios         map[string]float64 // by evidence type, penaltyRatioM if not listed
+	MaxEvidenceAge int64              // 0 for no limit
+	ReporterRate   float64            // share of the slashed stake for the proposer
+	ToPool         bool               // slashed stake goes to the community pool
+}
+
 func PenalizeConvicts(
 	store *store.Store,
 	logger log.Logger,
 
+	height int64,
+	proposer crypto.Address,
 	evidences []abci.Evidence,
 	lazyValidators []crypto.Address,
 
 	weightValidator, weightDelegator float64,
 	penaltyRatioM, penaltyRatioL float64,
+	params PenaltyParams,
 ) (bool, []abci.Event, error) {
 	doValUpdate := false
 	events := []abci.Event{}
@@ -35,21 +47,58 @@ func PenalizeConvicts(
 	// handle evidences
 	for _, evidence := range evidences {
 		validator := evidence.GetValidator().Address
-		tmp, evs, err := penalize(
+		if params.MaxEvidenceAge > 0 &&
+			height-evidence.Height > params.MaxEvidenceAge {
+			logger.Debug("Evidence Penalty", "validator",
+				hex.EncodeToString(validator), "too old evidence", evidence.Height)
+			continue
+		}
+		ratio, ok := params.Ratios[evidence.Type]
+		if !ok {
+			ratio = penaltyRatioM
+		}
+		tmp, slashed, evs, err := penalize(
 			store, logger,
 			weightValidator, weightDelegator,
-			validator, penaltyRatioM, "Evidence Penalty",
+			validator, ratio, "Evidence Penalty",
 		)
 		doValUpdate = doValUpdate || tmp
 		if err != nil {
 			return doValUpdate, events, err
 		}
 		events = append(events, evs...)
+
+		// reward the proposer who included the evidence
+		reward := new(types.Currency)
+		if proposer != nil && params.ReporterRate > 0 {
+			sf := new(big.Float).SetInt(&slashed.Int)
+			rf := new(big.Float).SetFloat64(params.ReporterRate)
+			sf.Mul(sf, rf)
+			sf.Int(&reward.Int)
+		}
+		if reward.Sign() > 0 {
+			balance := store.GetBalance(proposer, false).Add(reward)
+			store.SetBalance(proposer, balance)
+			addressJson, _ := json.Marshal(proposer)
+			amountJson, _ := json.Marshal(reward)
+			events = append(events, abci.Event{
+				Type: "reporter_reward",
+				Attributes: []kv.Pair{
+					{Key: []byte("address"), Value: addressJson},
+					{Key: []byte("amount"), Value: amountJson},
+				},
+			})
+		}
+		if params.ToPool {
+			slashed.Sub(reward)
+			pool := store.GetCommunityPool(false).Add(&slashed)
+			store.SetCommunityPool(pool)
+		}
 	}
 
 	// handle lazyValidators
 	for _, lazyValidator := range lazyValidators {
-		tmp, evs, err := penalize(
+		tmp, slashed, evs, err := penalize(
 			store, logger,
 			weightValidator, weightDelegator,
 			lazyValidator, penaltyRatioL, "Downtime Penalty",
@@ -59,6 +108,10 @@ func PenalizeConvicts(
 			return doValUpdate, events, err
 		}
 		events = append(events, evs...)
+		if params.ToPool {
+			pool := store.GetCommunityPool(false).Add(&slashed)
+			store.SetCommunityPool(pool)
+		}
 	}
 
 	return doValUpdate, events, nil
@@ -72,18 +125,23 @@ func penalize(
 	validator crypto.Address,
 	ratio float64,
 	penaltyType string,
-) (bool, []abci.Event, error) {
+) (bool, types.Currency, []abci.Event, error) {
 	doValUpdate := false
 	events := []abci.Event{}
 	zeroAmount := new(types.Currency).Set(0)
+	slashed := types.Currency{}
 
 	holder := store.GetHolderByValidator(validator, false)
 	if holder == nil {
-		return doValUpdate, events, fmt.Errorf("no holder for validator: %X", validator)
+		// evidence against a key which has been rotated out
+		holder = store.GetHolderByRetiredValidator(validator, false)
+	}
+	if holder == nil {
+		return doValUpdate, slashed, events, fmt.Errorf("no holder for validator: %X", validator)
 	}
 	vs := store.GetStake(holder, false) // validator's stake
 	if vs == nil {
-		return doValUpdate, events, fmt.Errorf("no stake for holder: %X", holder)
+		return doValUpdate, slashed, events, fmt.Errorf("no stake for holder: %X", holder)
 	}
 
 	ds := store.GetDelegatesByDelegatee(holder, false) // delegators' stake
@@ -151,11 +209,16 @@ func penalize(
 	}
 	// calc voter(validator) penalty
 	tmpc2.Int.Sub(&penalty.Int, &tmpc.Int)
+	slashed.Add(&tmpc)
 	if tmpc2.Equals(zeroAmount) {
-		return doValUpdate, events, nil
+		return doValUpdate, slashed, events, nil
 	}
 	// update stake
 	store.SlashStakes(holder, tmpc2, false)
+	if after := store.GetStake(holder, false); after != nil {
+		vs.Amount.Sub(&after.Amount)
+	}
+	slashed.Add(&vs.Amount)
 	// log XXX: remove this?
 	logger.Debug(penaltyType,
 		"validator", hex.EncodeToString(holder), "penalty", tmpc2.String())
@@ -171,5 +234,5 @@ func penalize(
 		},
 	})
 
-	return doValUpdate, events, nil
+	return doValUpdate, slashed, events, nil
 }
@@ -39,6 +39,13 @@ const (
 	TxCodeNoStorage
 	TxCodeUDCNotFound
 	TxCodeNotFound
+	TxCodeUnknownTxType
+	TxCodeUnknownField
+	TxCodeBadField
+	TxCodeBadSequence
+	TxCodeBadChainID
+	TxCodeInsufficientFee
+	TxCodeExceededBlockCost
 	TxCodeUnknown uint32 = 1000
 )
 
@@ -85,6 +92,13 @@ var errMap map[uint32]error = map[uint32]error{
 	TxCodeNoStorage:             errors.New("NoStorage"),
 	TxCodeUDCNotFound:           errors.New("UDCNotFound"),
 	TxCodeNotFound:              errors.New("NotFound"),
+	TxCodeUnknownTxType:         errors.New("UnknownTxType"),
+	TxCodeUnknownField:          errors.New("UnknownField"),
+	TxCodeBadField:              errors.New("BadField"),
+	TxCodeBadSequence:           errors.New("BadSequence"),
+	TxCodeBadChainID:            errors.New("BadChainID"),
+	TxCodeInsufficientFee:       errors.New("InsufficientFee"),
+	TxCodeExceededBlockCost:     errors.New("ExceededBlockCost"),
 	TxCodeUnknown:               errors.New("Unknown"),
 
 	QueryCodeBadPath: errors.New("BadPath"),
@@ -41,6 +41,13 @@ func ParseGenesisStateBytes(data []byte) (*GenAmoAppState, error) {
 	var genConfig struct {
 		Config types.AMOAppConfig `json:"config"`
 	}
+	// fields missing in genesis keep their defaults, while those set to zero
+	// explicitly stay zero
+	defaultConfig, err := types.NewDefaultAMOAppConfig()
+	if err != nil {
+		return &genState, err
+	}
+	genConfig.Config = defaultConfig
 	if len(data) > 0 {
 		err := json.Unmarshal(data, &genConfig)
 		if err != nil {
@@ -48,6 +55,8 @@ func ParseGenesisStateBytes(data []byte) (*GenAmoAppState, error) {
 		}
 	}
 	genState.Config = genConfig.Config
+	// NOTE: zero values of the fields below get replaced by the defaults as
+	// well, as genesis of the chains running has been parsed in this way
 	if genState.Config.MaxValidators == 0 {
 		genState.Config.MaxValidators = types.DefaultMaxValidators
 	}
@@ -150,7 +159,12 @@ func FillGenesisState(st *State, s *store.Store, genState *GenAmoAppState) error
 
 	// app config
 	// TODO: use reflect package
-	b, err := json.Marshal(genState.Config)
+	var b []byte
+	if st.ProtocolVersion < 0x7 {
+		b, err = genState.Config.MarshalLegacy()
+	} else {
+		b, err = json.Marshal(genState.Config)
+	}
 	if err != nil {
 		return err
 	}
@@ -108,6 +108,28 @@ func TestParseGenesisStateBytes(t *testing.T) {
 	genState, err = ParseGenesisStateBytes(stateBytes)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(genState.Stakes))
+
+	// missing config fields get defaults
+	assert.Equal(t, types.DefaultEarlyUnlockPenaltyRate,
+		genState.Config.EarlyUnlockPenaltyRate)
+	assert.Equal(t, types.DefaultDraftVetoRate, genState.Config.DraftVetoRate)
+
+	// zero rates set explicitly
+	stateBytes = []byte(`{"config": {
+		"early_unlock_penalty_rate": 0,
+		"draft_veto_rate": 0,
+		"draft_initial_deposit_rate": 0,
+		"draft_withdraw_penalty_rate": 0,
+		"draft_max_active": 0
+	}}`)
+	genState, err = ParseGenesisStateBytes(stateBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), genState.Config.EarlyUnlockPenaltyRate)
+	assert.Equal(t, float64(0), genState.Config.DraftVetoRate)
+	assert.Equal(t, float64(0), genState.Config.DraftInitialDepositRate)
+	assert.Equal(t, float64(0), genState.Config.DraftWithdrawPenaltyRate)
+	assert.Equal(t, uint64(0), genState.Config.DraftMaxActive)
+	assert.Equal(t, types.DefaultMaxValidators, genState.Config.MaxValidators)
 }
 
 func TestFillGenesisState(t *testing.T) {
@@ -151,3 +173,31 @@ func TestFillGenesisState(t *testing.T) {
 	valAddr, _ := hex.DecodeString(valAddrJson)
 	assert.Equal(t, addr0, s.GetHolderByValidator(valAddr, false))
 }
+
+func TestFillGenesisStateLegacyConfig(t *testing.T) {
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	st := State{}
+
+	// config stored by the binaries before v7
+	legacy := `{"max_validators":100,"weight_validator":2,"weight_delegator":1,"min_staking_unit":"1000000000000000000000000","blk_reward":"0","tx_reward":"10000000000000000000","penalty_ratio_m":0.3,"penalty_ratio_l":0.3,"laziness_window":10000,"laziness_threshold":8000,"hibernate_threshold":100,"hibernate_period":10000,"block_binding_window":10000,"lockup_period":1000000,"draft_open_count":10000,"draft_close_count":10000,"draft_apply_count":10000,"draft_deposit":"1000000000000000000000000","draft_quorum_rate":0.3,"draft_pass_rate":0.51,"draft_refund_rate":0.2,"upgrade_protocol_height":1,"upgrade_protocol_version":0}`
+
+	genState, err := ParseGenesisStateBytes([]byte(t0json))
+	assert.NoError(t, err)
+	err = FillGenesisState(&st, s, genState)
+	assert.NoError(t, err)
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+	assert.Equal(t, legacy, string(s.GetAppConfig()))
+
+	// genesis of v7 keeps the fields added
+	s, err = store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	genState, err = ParseGenesisStateBytes([]byte(`{"state":{"protocol_version":7}}`))
+	assert.NoError(t, err)
+	err = FillGenesisState(&st, s, genState)
+	assert.NoError(t, err)
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+	assert.Contains(t, string(s.GetAppConfig()), `"early_unlock_penalty_rate":0.1`)
+}
@@ -1,9 +1,14 @@
 package amo
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
 
-	"github.com/amolabs/amoabci/amo/types"
+	"github.com/tendermint/tendermint/libs/log"
+	tmdb "github.com/tendermint/tm-db"
+
+	astore "github.com/amolabs/amoabci/amo/store"
 )
 
 const Migration string = "ProtocolMigration"
@@ -14,79 +19,165 @@ var (
 	DataDirPath string = ""
 )
 
-func (app *AMOApp) migrateTo(protocolVersion uint64, changes []string, operation func() error) {
-	// condition for migration
-	if app.config.UpgradeProtocolHeight == types.DefaultUpgradeProtocolHeight ||
-		app.state.Height != app.config.UpgradeProtocolHeight {
-		return
+// ProtocolMigration describes the store changes needed when the protocol gets
+// upgraded to a certain version. Operation works only on the store and the
+// state given, so that it can run against a copy of the merkle DB as well as a
+// live chain.
+type ProtocolMigration struct {
+	Changes   []string
+	Operation func(s *astore.Store, st State, logger log.Logger) error
+}
+
+// RunMigration runs the migration of the protocol version on the working tree
+// of the store, and returns the working tree hash before and after the
+// migration. The state given is the one before the upgrade.
+// NOTE: available since protocol v5, as older protocol versions are not
+// recorded in the store.
+func RunMigration(s *astore.Store, st State, logger log.Logger,
+	protocolVersion uint64) ([]byte, []byte, error) {
+	before := s.Root()
+	proto, ok := AMOProtocolVersions[protocolVersion]
+	if !ok || proto.Migration() == nil {
+		return before, before, nil
 	}
+	migration := proto.Migration()
 
-	app.logger.Info(Migration+" - BEGIN", "ProtocolVersion", protocolVersion)
-	for i, change := range changes {
-		app.logger.Info(Migration, fmt.Sprintf("change %d", i+1), change)
+	logger.Info(Migration+" - BEGIN", "ProtocolVersion", protocolVersion,
+		"checksum", fmt.Sprintf("%X", before))
+	for i, change := range migration.Changes {
+		logger.Info(Migration, fmt.Sprintf("change %d", i+1), change)
 	}
 
-	err := operation()
+	err := migration.Operation(s, st, logger)
 	if err != nil {
-		panic(err)
+		return before, s.Root(), err
 	}
 
-	app.logger.Info(Migration+" - DONE", "ProtocolVersion", protocolVersion)
+	after := s.Root()
+	logger.Info(Migration+" - DONE", "ProtocolVersion", protocolVersion,
+		"checksum", fmt.Sprintf("%X", after))
+
+	return before, after, nil
 }
 
-func (app *AMOApp) MigrateToX() {
-	protocolVersion := uint64(0)
-	changes := []string{
-		"sample changes description",
-		"describe changes happening on this migration",
+func (app *AMOApp) migrateTo(protocolVersion uint64) error {
+	// run once, i.e. not again when reloading the state at the upgrade height
+	if app.store.GetProtocolVersion(false) >= protocolVersion {
+		return nil
 	}
 
-	app.migrateTo(protocolVersion, changes, func() error { return nil })
+	_, _, err := RunMigration(app.store, app.state, app.logger, protocolVersion)
+	return err
 }
 
-/* sample code for migration
+// MigrationReport is the result of a migration rehearsed offline.
+type MigrationReport struct {
+	Height          int64          `json:"height"`
+	ProtocolVersion uint64         `json:"protocol_version"`
+	ChecksumBefore  []byte         `json:"checksum_before"`
+	AppHash         []byte         `json:"app_hash"`
+	Changes         map[string]int `json:"changes"` // changed keys by prefix
+	Error           string         `json:"error,omitempty"`
+}
 
-func (app *AMOApp) MigrateTo5() {
-	protocolVersion := uint64(5)
-	changes := []string{
-		"shorten store key 'balance:' -> 'bal:'",
-		"set key-value with new prefix",
-		"remove exisiting key-value with old prefix",
+// MigrateDryRun rehearses the protocol upgrade at a height against the merkle
+// and index DBs given, which are supposed to be copies of a node's DBs as the
+// DBs get modified. The migration runs on the state committed at height-1, so
+// the height should be one of the checkpoints kept in the merkle DB, or 0 for
+// the height next to the latest one. Chain id is used only when the store has
+// none recorded, i.e. before protocol v7.
+// AppHash of the report is the hash committed right after the migration, which
+// leaves out the changes made by the txs and the rest of the upgrade block.
+func MigrateDryRun(merkleDB, indexDB tmdb.DB, height int64,
+	protocolVersion uint64, chainID string, logger log.Logger) (
+	*MigrationReport, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	err := checkProtocolVersion(protocolVersion)
+	if err != nil {
+		return nil, err
 	}
 
-	app.migrateTo(protocolVersion, changes, func() error {
-		// ignore when merkle tree doesn't have available versions
-		_, err := app.store.GetLatestVersion()
-		if err != nil {
-			return nil
-		}
-
-		imt, err := app.store.GetImmutableTree(true)
-		if err != nil {
-			return err
-		}
-
-		beforeKeyPrefix := []byte("balance:")
-		afterKeyPrefix := []byte("bal:")
+	s, err := astore.NewStore(logger, 1, merkleDB, indexDB)
+	if err != nil {
+		return nil, err
+	}
+	// NOTE: merkle version equals to last height + 1
+	if height > 0 {
+		_, err = s.LoadVersion(height)
+	} else {
+		_, err = s.Load()
+		height = s.GetMerkleVersion()
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.RebuildIndex()
 
-		imt.IterateRangeInclusive(beforeKeyPrefix, nil, true, func(key, value []byte, version int64) bool {
-			if !bytes.HasPrefix(key, beforeKeyPrefix) {
-				return false
-			}
+	before, err := snapshotStore(s)
+	if err != nil {
+		return nil, err
+	}
 
-			beforeKey := key
-			afterKey := append(afterKeyPrefix, key[len(beforeKeyPrefix):]...)
+	report := &MigrationReport{
+		Height:          height,
+		ProtocolVersion: protocolVersion,
+		Changes:         map[string]int{},
+	}
+	st := State{}
+	st.InferFrom(s)
+	if len(st.ChainID) == 0 {
+		st.ChainID = chainID
+	}
+	report.ChecksumBefore, _, err = RunMigration(s, st, logger, protocolVersion)
+	if err != nil {
+		report.Error = err.Error()
+	}
+	if protocolVersion > 4 {
+		s.SetProtocolVersion(protocolVersion)
+	}
 
-			app.logger.Debug(Migration, "store:set", fmt.Sprintf("%x", afterKey))
-			app.store.Set(afterKey, value)
-			app.logger.Debug(Migration, "store:remove", fmt.Sprintf("%x", beforeKey))
-			app.store.Remove(beforeKey)
+	after, err := snapshotStore(s)
+	if err != nil {
+		return report, err
+	}
+	if len(report.Error) == 0 {
+		// as Commit does
+		report.AppHash, _, err = s.Save()
+		if err != nil {
+			return report, err
+		}
+	}
+	for key, hash := range after {
+		if old, ok := before[key]; !ok || !bytes.Equal(old, hash) {
+			report.Changes[keyPrefix([]byte(key))] += 1
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			report.Changes[keyPrefix([]byte(key))] += 1
+		}
+	}
 
-			return false
-		})
+	return report, nil
+}
 
-		return nil
+// snapshotStore returns value hashes of every key in the working tree.
+func snapshotStore(s *astore.Store) (map[string][]byte, error) {
+	snapshot := map[string][]byte{}
+	err := s.Iterate(false, func(key, value []byte) bool {
+		hash := sha256.Sum256(value)
+		snapshot[string(key)] = hash[:]
+		return false
 	})
+	return snapshot, err
 }
 
-*/
+func keyPrefix(key []byte) string {
+	i := bytes.IndexByte(key, ':')
+	if i < 0 {
+		return string(key)
+	}
+	return string(key[:i+1])
+}
@@ -21,6 +21,32 @@ import (
 //   So, it is mandatory to use 'true' for 'committed' arg input
 //   to query data from merkle tree
 
+// QueryHandler serves a query path, where args are the path elements
+// following the first one.
+type QueryHandler func(app *AMOApp, args []string, queryData []byte) abci.ResponseQuery
+
+// QueryRoutes maps the first element of query paths to their handlers. Each
+// protocol version has its own routes, so that query paths and response
+// formats may change without affecting those for older protocol versions.
+type QueryRoutes map[string]QueryHandler
+
+// extend returns new routes adding or replacing the routes given.
+func (routes QueryRoutes) extend(more QueryRoutes) QueryRoutes {
+	newRoutes := QueryRoutes{}
+	for path, handler := range routes {
+		newRoutes[path] = handler
+	}
+	for path, handler := range more {
+		newRoutes[path] = handler
+	}
+	return newRoutes
+}
+
+func badPath() (res abci.ResponseQuery) {
+	res.Code = code.QueryCodeBadPath
+	return
+}
+
 func queryVersion(app *AMOApp) (res abci.ResponseQuery) {
 	var r struct {
 		AppVersion           string   `json:"app_version,omitempty"`
@@ -52,6 +78,20 @@ func queryVersion(app *AMOApp) (res abci.ResponseQuery) {
 	return
 }
 
+func queryTxTypes(app *AMOApp) (res abci.ResponseQuery) {
+	txTypes := []string{}
+	if app.proto != nil {
+		txTypes = app.proto.TxRegistry().Types()
+	}
+
+	jsonstr, _ := json.Marshal(txTypes)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+
+	return
+}
+
 func queryAppConfig(config types.AMOAppConfig) (res abci.ResponseQuery) {
 	jsonstr, _ := json.Marshal(config)
 	res.Log = string(jsonstr)
@@ -193,6 +233,62 @@ func queryStake(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	return
 }
 
+func queryLockedStake(s *store.Store, lastHeight int64, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var addr crypto.Address
+	err := json.Unmarshal(queryData, &addr)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	stake := s.GetStake(addr, true)
+	if stake == nil {
+		res.Log = "error: no stake"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	lockedStakeEx := types.LockedStakeEx{
+		Validator:    stake.Validator[:],
+		LockedStakes: []*types.LockedStake{},
+	}
+	unlocked := s.GetUnlockedStake(addr, true)
+	if unlocked != nil {
+		lockedStakeEx.Unlocked = unlocked.Amount
+	}
+	// A locked stake is loosened by one at every EndBlock and gets unlocked
+	// when its remaining height reaches one.
+	stakes, heights := s.GetLockedStakesWithHeight(addr, true)
+	for i, stake := range stakes {
+		lockedStakeEx.Locked.Add(&stake.Amount)
+		lockedStakeEx.LockedStakes = append(lockedStakeEx.LockedStakes,
+			&types.LockedStake{
+				Amount:       stake.Amount,
+				Remaining:    heights[i],
+				UnlockHeight: lastHeight + heights[i],
+			})
+	}
+	sort.Slice(lockedStakeEx.LockedStakes, func(i, j int) bool {
+		return lockedStakeEx.LockedStakes[i].Remaining <
+			lockedStakeEx.LockedStakes[j].Remaining
+	})
+
+	jsonstr, _ := json.Marshal(lockedStakeEx)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
 func queryDelegate(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	if len(queryData) == 0 {
 		res.Log = "error: no query_data"
@@ -249,6 +345,89 @@ func queryValidator(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	return
 }
 
+func queryValidators(s *store.Store, config types.AMOAppConfig) (res abci.ResponseQuery) {
+	powers := s.GetVotingPowers(config.MaxValidators,
+		config.MaxVotingPowerRate, config.SelfStakeRule(), true)
+	if powers == nil {
+		powers = []*types.VotingPower{}
+	}
+
+	jsonstr, _ := json.Marshal(powers)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+
+	return
+}
+
+func queryCommunityPool(s *store.Store) (res abci.ResponseQuery) {
+	pool := s.GetCommunityPool(true)
+
+	jsonstr, _ := json.Marshal(pool)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+
+	return
+}
+
+func queryUpgradePlan(s *store.Store) (res abci.ResponseQuery) {
+	plan := s.GetUpgradePlan(true)
+	if plan == nil {
+		res.Log = "error: no upgrade plan"
+		res.Code = code.QueryCodeNoMatch
+		return
+	}
+
+	jsonstr, _ := json.Marshal(plan)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+
+	return
+}
+
+func queryFee(config *types.AMOAppConfig) (res abci.ResponseQuery) {
+	minFees := config.MinFees
+	if minFees == nil {
+		minFees = map[string]types.FeeRate{}
+	}
+
+	jsonstr, _ := json.Marshal(minFees)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+
+	return
+}
+
+func querySequence(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+	if len(queryData) == 0 {
+		res.Log = "error: no query_data"
+		res.Code = code.QueryCodeNoKey
+		return
+	}
+
+	var addr crypto.Address
+	err := json.Unmarshal(queryData, &addr)
+	if err != nil {
+		res.Log = "error: unmarshal"
+		res.Code = code.QueryCodeBadKey
+		return
+	}
+
+	// last sequence used, 0 if none
+	seq := s.GetSequence(addr, true)
+
+	jsonstr, _ := json.Marshal(seq)
+	res.Log = string(jsonstr)
+	res.Value = jsonstr
+	res.Code = code.QueryCodeOK
+	res.Key = queryData
+
+	return
+}
+
 func queryHibernate(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	if len(queryData) == 0 {
 		res.Log = "error: no query_data"
@@ -310,7 +489,7 @@ func queryStorage(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	return
 }
 
-func queryDraft(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
+func queryDraft(s *store.Store, config *types.AMOAppConfig, queryData []byte) (res abci.ResponseQuery) {
 	if len(queryData) == 0 {
 		res.Log = "error: no query_data"
 		res.Code = code.QueryCodeNoKey
@@ -335,6 +514,17 @@ func queryDraft(s *store.Store, queryData []byte) (res abci.ResponseQuery) {
 	draftEx := types.DraftEx{
 		DraftForQuery: draft,
 		Votes:         s.GetVotes(draftID, true),
+		Deposits:      s.GetDraftDeposits(draftID, true),
+	}
+
+	// projected tally while the vote is open
+	if config != nil &&
+		draft.OpenCount == 0 && draft.CloseCount > 0 && draft.ApplyCount > 0 {
+		draftEx.Tally = s.TallyDraft(draftID, draft.Proposer,
+			config.MaxValidators, config.SelfStakeRule(),
+			config.DraftQuorumRate, config.DraftPassRate,
+			config.DraftRefundRate, config.DraftVetoRate,
+			false, true)
 	}
 
 	jsonstr, err := json.Marshal(draftEx)
@@ -12,6 +12,7 @@ type State struct {
 	LastHeight      int64  `json:"-"` // last completed block height
 	LastAppHash     []byte `json:"-"`
 	NextDraftID     uint32 `json:"-"`
+	ChainID         string `json:"-"`
 }
 
 func (s *State) InferFrom(sto *store.Store) {
@@ -28,6 +29,7 @@ func (s *State) InferFrom(sto *store.Store) {
 	s.LastHeight = height
 	s.LastAppHash = hash
 	s.NextDraftID = nextDraftID
+	s.ChainID = sto.GetChainID(false)
 
 	s.ProtocolVersion = sto.GetProtocolVersion(false)
 	if s.ProtocolVersion == 0 {
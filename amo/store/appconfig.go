@@ -1,5 +1,9 @@
 package store
 
+import (
+	"github.com/amolabs/amoabci/amo/types"
+)
+
 func (s Store) SetAppConfig(b []byte) error {
 	s.set([]byte("config"), b)
 	return nil
@@ -9,3 +13,20 @@ func (s Store) GetAppConfig() []byte {
 	// always get config from committed tree
 	return s.get([]byte("config"), true)
 }
+
+// GetWorkingAppConfig returns config in the working tree, which differs from
+// the committed one when a config change gets applied in the current block.
+func (s Store) GetWorkingAppConfig() []byte {
+	return s.get([]byte("config"), false)
+}
+
+// MergeAppConfig merges a partial config into the working config, so that
+// diffs applied in the same block accumulate.
+func (s Store) MergeAppConfig(diff []byte) ([]byte, error) {
+	b, err := types.MergeConfigDiff(s.get([]byte("config"), false), diff)
+	if err != nil {
+		return nil, err
+	}
+	s.set([]byte("config"), b)
+	return b, nil
+}
@@ -0,0 +1,291 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// cacheDB keeps writes in memory on top of a parent DB, which never gets
+// written to. Used as the index DB of a store branch.
+type cacheDB struct {
+	mtx    sync.Mutex
+	parent tmdb.DB
+	cache  map[string]cacheValue
+}
+
+type cacheValue struct {
+	value   []byte
+	deleted bool
+}
+
+type cacheItem struct {
+	key []byte
+	cacheValue
+}
+
+var _ tmdb.DB = (*cacheDB)(nil)
+
+func newCacheDB(parent tmdb.DB) *cacheDB {
+	return &cacheDB{
+		parent: parent,
+		cache:  make(map[string]cacheValue),
+	}
+}
+
+func (db *cacheDB) Get(key []byte) ([]byte, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if v, ok := db.cache[string(key)]; ok {
+		if v.deleted {
+			return nil, nil
+		}
+		return v.value, nil
+	}
+	return db.parent.Get(key)
+}
+
+func (db *cacheDB) Has(key []byte) (bool, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if v, ok := db.cache[string(key)]; ok {
+		return !v.deleted, nil
+	}
+	return db.parent.Has(key)
+}
+
+func (db *cacheDB) Set(key, value []byte) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	db.set(key, value)
+	return nil
+}
+
+func (db *cacheDB) SetSync(key, value []byte) error {
+	return db.Set(key, value)
+}
+
+func (db *cacheDB) Delete(key []byte) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	db.delete(key)
+	return nil
+}
+
+func (db *cacheDB) DeleteSync(key []byte) error {
+	return db.Delete(key)
+}
+
+func (db *cacheDB) set(key, value []byte) {
+	db.cache[string(key)] = cacheValue{value: value}
+}
+
+func (db *cacheDB) delete(key []byte) {
+	db.cache[string(key)] = cacheValue{deleted: true}
+}
+
+// cachedItems returns the cached items in the domain, sorted in the order of
+// iteration.
+func (db *cacheDB) cachedItems(start, end []byte, reverse bool) []cacheItem {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	items := []cacheItem{}
+	for k, v := range db.cache {
+		if !tmdb.IsKeyInDomain([]byte(k), start, end) {
+			continue
+		}
+		items = append(items, cacheItem{key: []byte(k), cacheValue: v})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if reverse {
+			return bytes.Compare(items[i].key, items[j].key) > 0
+		}
+		return bytes.Compare(items[i].key, items[j].key) < 0
+	})
+	return items
+}
+
+func (db *cacheDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	it, err := db.parent.Iterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newCacheIterator(it, db.cachedItems(start, end, false), false), nil
+}
+
+func (db *cacheDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	it, err := db.parent.ReverseIterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newCacheIterator(it, db.cachedItems(start, end, true), true), nil
+}
+
+func (db *cacheDB) Close() error {
+	return nil
+}
+
+func (db *cacheDB) NewBatch() tmdb.Batch {
+	return &cacheBatch{db: db}
+}
+
+func (db *cacheDB) Print() error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	for k, v := range db.cache {
+		fmt.Printf("[%X]:\t[%X] (deleted: %t)\n", []byte(k), v.value, v.deleted)
+	}
+	return nil
+}
+
+func (db *cacheDB) Stats() map[string]string {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	return map[string]string{
+		"database.type": "cacheDB",
+		"database.size": fmt.Sprintf("%d", len(db.cache)),
+	}
+}
+
+type cacheBatch struct {
+	db  *cacheDB
+	ops []cacheOp
+}
+
+type cacheOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+func (b *cacheBatch) Set(key, value []byte) {
+	b.ops = append(b.ops, cacheOp{key: key, value: value})
+}
+
+func (b *cacheBatch) Delete(key []byte) {
+	b.ops = append(b.ops, cacheOp{key: key, delete: true})
+}
+
+func (b *cacheBatch) Write() error {
+	b.db.mtx.Lock()
+	defer b.db.mtx.Unlock()
+
+	for _, op := range b.ops {
+		if op.delete {
+			b.db.delete(op.key)
+		} else {
+			b.db.set(op.key, op.value)
+		}
+	}
+	b.ops = nil
+	return nil
+}
+
+func (b *cacheBatch) WriteSync() error {
+	return b.Write()
+}
+
+func (b *cacheBatch) Close() {
+	b.ops = nil
+}
+
+// cacheIterator walks the parent iterator lazily, merging the cached items
+// into it. Cached items take the place of the parent items of the same key.
+type cacheIterator struct {
+	parent  tmdb.Iterator
+	items   []cacheItem
+	reverse bool
+
+	key   []byte
+	value []byte
+	valid bool
+}
+
+var _ tmdb.Iterator = (*cacheIterator)(nil)
+
+func newCacheIterator(parent tmdb.Iterator, items []cacheItem, reverse bool) *cacheIterator {
+	it := &cacheIterator{
+		parent:  parent,
+		items:   items,
+		reverse: reverse,
+	}
+	it.Next()
+	return it
+}
+
+func (it *cacheIterator) Domain() ([]byte, []byte) {
+	return it.parent.Domain()
+}
+
+func (it *cacheIterator) Valid() bool {
+	return it.valid
+}
+
+func (it *cacheIterator) Next() {
+	for {
+		fromParent := it.parent.Valid()
+		if fromParent && len(it.items) > 0 {
+			c := bytes.Compare(it.parent.Key(), it.items[0].key)
+			if it.reverse {
+				c = -c
+			}
+			if c == 0 {
+				// overwritten by the cached one
+				it.parent.Next()
+			}
+			fromParent = c < 0
+		}
+
+		if fromParent {
+			it.key = append([]byte{}, it.parent.Key()...)
+			it.value = append([]byte{}, it.parent.Value()...)
+			it.valid = true
+			it.parent.Next()
+			return
+		}
+		if len(it.items) == 0 {
+			it.key, it.value, it.valid = nil, nil, false
+			return
+		}
+
+		item := it.items[0]
+		it.items = it.items[1:]
+		if item.deleted {
+			continue
+		}
+		it.key, it.value, it.valid = item.key, item.value, true
+		return
+	}
+}
+
+func (it *cacheIterator) Key() []byte {
+	if !it.valid {
+		panic("cacheIterator is invalid")
+	}
+	return it.key
+}
+
+func (it *cacheIterator) Value() []byte {
+	if !it.valid {
+		panic("cacheIterator is invalid")
+	}
+	return it.value
+}
+
+func (it *cacheIterator) Error() error {
+	return it.parent.Error()
+}
+
+func (it *cacheIterator) Close() {
+	it.parent.Close()
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	tmdb "github.com/tendermint/tm-db"
+)
+
+func TestCacheDBIterator(t *testing.T) {
+	parent := tmdb.NewMemDB()
+	parent.Set([]byte("a"), []byte("1"))
+	parent.Set([]byte("c"), []byte("3"))
+	parent.Set([]byte("e"), []byte("5"))
+	parent.Set([]byte("g"), []byte("7"))
+
+	db := newCacheDB(parent)
+	db.Set([]byte("b"), []byte("2"))
+	db.Set([]byte("c"), []byte("33"))
+	db.Delete([]byte("e"))
+	db.Set([]byte("f"), []byte("6"))
+	db.Delete([]byte("x"))
+
+	collect := func(it tmdb.Iterator) []string {
+		defer it.Close()
+		kvs := []string{}
+		for ; it.Valid(); it.Next() {
+			kvs = append(kvs, string(it.Key())+"="+string(it.Value()))
+		}
+		return kvs
+	}
+
+	it, err := db.Iterator(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a=1", "b=2", "c=33", "f=6", "g=7"}, collect(it))
+
+	it, err = db.ReverseIterator(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"g=7", "f=6", "c=33", "b=2", "a=1"}, collect(it))
+
+	it, err = db.Iterator([]byte("b"), []byte("f"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b=2", "c=33"}, collect(it))
+
+	it, err = db.ReverseIterator([]byte("b"), []byte("g"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f=6", "c=33", "b=2"}, collect(it))
+
+	// parent never gets written to
+	v, _ := parent.Get([]byte("c"))
+	assert.Equal(t, []byte("3"), v)
+	v, _ = parent.Get([]byte("e"))
+	assert.Equal(t, []byte("5"), v)
+}
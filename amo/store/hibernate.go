@@ -49,6 +49,7 @@ func (s Store) GetHibernates(committed bool) (vals []crypto.Address, hibs []*typ
 	copy(end, start)
 	end[prefixLen-1] = ';'
 	s.merkleTree.IterateRange(start, end, true, func(k, v []byte) bool {
+		s.meterRead(k, v)
 		val := k[prefixLen : prefixLen+crypto.AddressSize]
 		var hib types.Hibernate
 		err := json.Unmarshal(v, &hib)
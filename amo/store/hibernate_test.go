@@ -53,15 +53,15 @@ func TestValidatorSelection(t *testing.T) {
 	s.SetUnlockedStake(a1, s1)
 	s.SetUnlockedStake(a2, s2)
 	s.SetUnlockedStake(a3, s3)
-	vals := s.GetValidators(10, false)
+	vals := s.GetValidators(10, 0, types.SelfStakeRule{}, false)
 	assert.Equal(t, 3, len(vals))
 
 	hib := makeHibernate(10, 100)
 	s.SetHibernate(s2.Validator.Address(), hib)
-	vals = s.GetValidators(10, false)
+	vals = s.GetValidators(10, 0, types.SelfStakeRule{}, false)
 	assert.Equal(t, 2, len(vals))
 
 	s.DeleteHibernate(s2.Validator.Address())
-	vals = s.GetValidators(10, false)
+	vals = s.GetValidators(10, 0, types.SelfStakeRule{}, false)
 	assert.Equal(t, 3, len(vals))
 }
@@ -0,0 +1,265 @@
+package store
+
+import (
+	"sync"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// cost of store access, in cost units
+const (
+	CostRead      = uint64(10)
+	CostReadByte  = uint64(1)
+	CostWrite     = uint64(100)
+	CostWriteByte = uint64(10)
+)
+
+// Meter counts store access made while a tx is being executed.
+type Meter struct {
+	Reads      uint64 `json:"reads"`
+	ReadBytes  uint64 `json:"read_bytes"`
+	Writes     uint64 `json:"writes"`
+	WriteBytes uint64 `json:"write_bytes"`
+}
+
+// Cost returns the execution cost of the store access counted so far.
+func (m Meter) Cost() uint64 {
+	return m.Reads*CostRead + m.ReadBytes*CostReadByte +
+		m.Writes*CostWrite + m.WriteBytes*CostWriteByte
+}
+
+func (m *Meter) read(key, value []byte) {
+	m.Reads += 1
+	m.ReadBytes += uint64(len(key) + len(value))
+}
+
+func (m *Meter) write(key, value []byte) {
+	m.Writes += 1
+	m.WriteBytes += uint64(len(key) + len(value))
+}
+
+// txScope keeps the meter and the original values of the keys written since
+// the beginning of a tx, so that the tx can be reverted.
+type txScope struct {
+	meter Meter
+
+	merkle     map[string]journalValue
+	merkleKeys [][]byte
+	index      map[string]journalValue
+	indexKeys  [][]byte
+}
+
+type journalValue struct {
+	value []byte
+	exist bool
+}
+
+func newTxScope() *txScope {
+	return &txScope{
+		merkle: make(map[string]journalValue),
+		index:  make(map[string]journalValue),
+	}
+}
+
+// BeginTx starts metering and journaling of the store access.
+func (s *Store) BeginTx() {
+	s.tx = newTxScope()
+	s.txIndexDB.setScope(s.tx)
+}
+
+// TxMeter returns the store access counted since BeginTx.
+func (s *Store) TxMeter() Meter {
+	if s.tx == nil {
+		return Meter{}
+	}
+	return s.tx.meter
+}
+
+// RevertTx restores the keys written since BeginTx to their original values.
+// Keys are restored in reverse order of the first write, so that every node
+// ends up with the same merkle tree.
+func (s *Store) RevertTx() {
+	tx := s.tx
+	if tx == nil {
+		return
+	}
+	s.EndTx()
+
+	for i := len(tx.merkleKeys) - 1; i >= 0; i-- {
+		key := tx.merkleKeys[i]
+		orig := tx.merkle[string(key)]
+		if orig.exist {
+			s.merkleTree.Set(key, orig.value)
+		} else {
+			s.merkleTree.Remove(key)
+		}
+	}
+	for i := len(tx.indexKeys) - 1; i >= 0; i-- {
+		key := tx.indexKeys[i]
+		orig := tx.index[string(key)]
+		if orig.exist {
+			s.indexDB.Set(key, orig.value)
+		} else {
+			s.indexDB.Delete(key)
+		}
+	}
+}
+
+// EndTx stops metering and journaling of the store access.
+func (s *Store) EndTx() {
+	s.tx = nil
+	s.txIndexDB.setScope(nil)
+}
+
+func (s *Store) meterRead(key, value []byte) {
+	if s.tx != nil {
+		s.tx.meter.read(key, value)
+	}
+}
+
+// recordMerkle meters a write to the merkle tree and keeps the original value
+// of the key on its first write in the tx.
+func (s *Store) recordMerkle(key, value []byte) {
+	if s.tx == nil {
+		return
+	}
+	s.tx.meter.write(key, value)
+	if _, ok := s.tx.merkle[string(key)]; ok {
+		return
+	}
+	_, orig := s.merkleTree.Get(key)
+	s.tx.merkle[string(key)] = journalValue{value: orig, exist: orig != nil}
+	s.tx.merkleKeys = append(s.tx.merkleKeys, key)
+}
+
+// txDB meters and journals access to the index DB while a tx is being
+// executed, and passes through otherwise.
+// NOTE: writes in a batch are neither metered nor journaled, as index DBs are
+// written in batch only out of tx execution.
+type txDB struct {
+	tmdb.DB
+
+	mtx   sync.Mutex
+	scope *txScope
+}
+
+var _ tmdb.DB = (*txDB)(nil)
+
+func newTxDB(db tmdb.DB) *txDB {
+	return &txDB{DB: db}
+}
+
+func (db *txDB) setScope(scope *txScope) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	db.scope = scope
+}
+
+func (db *txDB) read(key, value []byte) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.scope != nil {
+		db.scope.meter.read(key, value)
+	}
+}
+
+func (db *txDB) record(key, value []byte) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.scope == nil {
+		return nil
+	}
+	db.scope.meter.write(key, value)
+	if _, ok := db.scope.index[string(key)]; ok {
+		return nil
+	}
+	orig, err := db.DB.Get(key)
+	if err != nil {
+		return err
+	}
+	db.scope.index[string(key)] = journalValue{value: orig, exist: orig != nil}
+	db.scope.indexKeys = append(db.scope.indexKeys, key)
+	return nil
+}
+
+func (db *txDB) Get(key []byte) ([]byte, error) {
+	value, err := db.DB.Get(key)
+	db.read(key, value)
+	return value, err
+}
+
+func (db *txDB) Has(key []byte) (bool, error) {
+	db.read(key, nil)
+	return db.DB.Has(key)
+}
+
+func (db *txDB) Set(key, value []byte) error {
+	err := db.record(key, value)
+	if err != nil {
+		return err
+	}
+	return db.DB.Set(key, value)
+}
+
+func (db *txDB) SetSync(key, value []byte) error {
+	err := db.record(key, value)
+	if err != nil {
+		return err
+	}
+	return db.DB.SetSync(key, value)
+}
+
+func (db *txDB) Delete(key []byte) error {
+	err := db.record(key, nil)
+	if err != nil {
+		return err
+	}
+	return db.DB.Delete(key)
+}
+
+func (db *txDB) DeleteSync(key []byte) error {
+	err := db.record(key, nil)
+	if err != nil {
+		return err
+	}
+	return db.DB.DeleteSync(key)
+}
+
+func (db *txDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	it, err := db.DB.Iterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newTxIterator(it, db), nil
+}
+
+func (db *txDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	it, err := db.DB.ReverseIterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newTxIterator(it, db), nil
+}
+
+// txIterator meters every item visited.
+type txIterator struct {
+	tmdb.Iterator
+	db *txDB
+}
+
+func newTxIterator(it tmdb.Iterator, db *txDB) *txIterator {
+	if it.Valid() {
+		db.read(it.Key(), it.Value())
+	}
+	return &txIterator{Iterator: it, db: db}
+}
+
+func (it *txIterator) Next() {
+	it.Iterator.Next()
+	if it.Iterator.Valid() {
+		it.db.read(it.Iterator.Key(), it.Iterator.Value())
+	}
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	keyCommunityPool = []byte("pool")
+)
+
+func (s Store) SetCommunityPool(amount *types.Currency) error {
+	b, err := json.Marshal(amount)
+	if err != nil {
+		return fmt.Errorf("Invalid community pool amount")
+	}
+
+	s.set(keyCommunityPool, b)
+
+	return nil
+}
+
+func (s Store) GetCommunityPool(committed bool) *types.Currency {
+	amount := new(types.Currency).Set(0)
+	b := s.get(keyCommunityPool, committed)
+	if len(b) == 0 {
+		return amount
+	}
+	err := json.Unmarshal(b, amount)
+	if err != nil {
+		return new(types.Currency).Set(0)
+	}
+	return amount
+}
+
+// spendCommunityPool pays the amount of a passed spend draft to its
+// recipient. The draft is skipped if the pool has run short since proposed.
+func (s Store) spendCommunityPool(draftID uint32, draft *types.Draft) []abci.Event {
+	events := []abci.Event{}
+
+	pool := s.GetCommunityPool(false)
+	if draft.Amount == nil || pool.LessThan(draft.Amount) {
+		return events
+	}
+
+	s.SetCommunityPool(pool.Sub(draft.Amount))
+	balance := s.GetBalance(draft.Recipient, false)
+	s.SetBalance(draft.Recipient, balance.Add(draft.Amount))
+
+	idJson, _ := json.Marshal(draftID)
+	addressJson, _ := json.Marshal(draft.Recipient)
+	amountJson, _ := json.Marshal(draft.Amount)
+	events = append(events, abci.Event{
+		Type: "draft_spend",
+		Attributes: []kv.Pair{
+			{Key: []byte("id"), Value: idJson},
+			{Key: []byte("address"), Value: addressJson},
+			{Key: []byte("amount"), Value: amountJson},
+		},
+	})
+
+	return events
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/tendermint/tendermint/crypto"
+)
+
+var (
+	prefixSequence = []byte("seq:")
+)
+
+func makeSequenceKey(addr crypto.Address) []byte {
+	return append(prefixSequence, addr...)
+}
+
+// SetSequence sets the last sequence number used by the account.
+func (s Store) SetSequence(addr crypto.Address, seq uint64) error {
+	b, err := json.Marshal(seq)
+	if err != nil {
+		return err
+	}
+
+	s.set(makeSequenceKey(addr), b)
+
+	return nil
+}
+
+// GetSequence returns the last sequence number used by the account, or 0 if
+// the account has never sent a tx with a sequence number.
+func (s Store) GetSequence(addr crypto.Address, committed bool) uint64 {
+	b := s.get(makeSequenceKey(addr), committed)
+	if len(b) == 0 {
+		return 0
+	}
+	var seq uint64
+	err := json.Unmarshal(b, &seq)
+	if err != nil {
+		return 0
+	}
+	return seq
+}
@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/tendermint/iavl"
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/libs/kv"
 	"github.com/tendermint/tendermint/libs/log"
 	tm "github.com/tendermint/tendermint/types"
@@ -28,7 +30,9 @@ const (
 )
 
 var (
-	protocolKey = []byte("protocol")
+	protocolKey     = []byte("protocol")
+	chainIDKey      = []byte("chain_id")
+	activeDraftsKey = []byte("active_drafts")
 
 	prefixBalance  = []byte("balance:")
 	prefixStake    = []byte("stake:")
@@ -38,6 +42,8 @@ var (
 	prefixParcel   = []byte("parcel:")
 	prefixRequest  = []byte("request:")
 	prefixUsage    = []byte("usage:")
+	prefixRetired  = []byte("retired:")
+	prefixDeposit  = []byte("deposit:")
 
 	prefixIndexDelegator = []byte("delegator")
 	prefixIndexValidator = []byte("validator")
@@ -51,11 +57,14 @@ type Store struct {
 
 	// merkle tree for blockchain state
 	merkleDB            tmdb.DB
+	recentDB            tmdb.DB // recent versions not yet in merkleDB
 	merkleTree          *iavl.MutableTree
 	merkleVersion       int64
 	checkpoint_interval int64
 
 	indexDB tmdb.DB
+	// index DB as seen by the search indexes, metered while executing a tx
+	txIndexDB *txDB
 	// search index for delegators:
 	// XXX: a delegatee can have multiple delegators
 	// key: delegatee address || delegator address
@@ -81,43 +90,77 @@ type Store struct {
 
 	// miss runs
 	missRunDB tmdb.DB
+
+	// meter and journal of the tx being executed, nil out of tx execution
+	tx *txScope
 }
 
 func NewStore(logger log.Logger, checkpoint_interval int64, merkleDB, indexDB tmdb.DB) (*Store, error) {
+	return newStore(logger, checkpoint_interval, merkleDB, tmdb.NewMemDB(),
+		indexDB)
+}
+
+func newStore(logger log.Logger, checkpoint_interval int64,
+	merkleDB, recentDB, indexDB tmdb.DB) (*Store, error) {
 	// normal noprune
 	//mt, err := iavl.NewMutableTree(merkleDB, merkleTreeCacheSize)
 	// with prune
-	memDB := tmdb.NewMemDB()
 	keepRecent := int64(0)
 	if checkpoint_interval > 1 {
 		keepRecent = 1
 	}
-	mt, err := iavl.NewMutableTreeWithOpts(merkleDB, memDB,
+	mt, err := iavl.NewMutableTreeWithOpts(merkleDB, recentDB,
 		merkleTreeCacheSize, iavl.PruningOptions(checkpoint_interval, keepRecent))
 
 	if err != nil {
 		return nil, err
 	}
 
+	txIndexDB := newTxDB(indexDB)
+
 	return &Store{
 		logger: logger,
 
 		merkleDB:            merkleDB,
+		recentDB:            recentDB,
 		merkleTree:          mt,
 		merkleVersion:       0,
 		checkpoint_interval: checkpoint_interval,
 
 		indexDB:        indexDB,
-		indexDelegator: tmdb.NewPrefixDB(indexDB, prefixIndexDelegator),
-		indexValidator: tmdb.NewPrefixDB(indexDB, prefixIndexValidator),
-		indexEffStake:  tmdb.NewPrefixDB(indexDB, prefixIndexEffStake),
-		indexBlockTx:   tmdb.NewPrefixDB(indexDB, prefixIndexBlockTx),
-		indexTxBlock:   tmdb.NewPrefixDB(indexDB, prefixIndexTxBlock),
-
-		missRunDB: tmdb.NewPrefixDB(indexDB, prefixMissRun),
+		txIndexDB:      txIndexDB,
+		indexDelegator: tmdb.NewPrefixDB(txIndexDB, prefixIndexDelegator),
+		indexValidator: tmdb.NewPrefixDB(txIndexDB, prefixIndexValidator),
+		indexEffStake:  tmdb.NewPrefixDB(txIndexDB, prefixIndexEffStake),
+		indexBlockTx:   tmdb.NewPrefixDB(txIndexDB, prefixIndexBlockTx),
+		indexTxBlock:   tmdb.NewPrefixDB(txIndexDB, prefixIndexTxBlock),
+
+		missRunDB: tmdb.NewPrefixDB(txIndexDB, prefixMissRun),
 	}, nil
 }
 
+// Branch returns a store on the committed state of s, where txs can run
+// without touching s. Changes to the branch live only in memory, and the
+// branch must not be saved.
+// NOTE: index DB is not versioned, so the branch reads indexes of s including
+// changes not yet committed.
+func (s *Store) Branch() (*Store, error) {
+	b, err := newStore(s.logger, s.checkpoint_interval, s.merkleDB, s.recentDB,
+		newCacheDB(s.indexDB))
+	if err != nil {
+		return nil, err
+	}
+	if s.merkleVersion > 0 {
+		_, err = b.merkleTree.LoadVersion(s.merkleVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b.merkleVersion = s.merkleVersion
+
+	return b, nil
+}
+
 func (s *Store) GetMerkleVersion() int64 {
 	return s.merkleVersion
 }
@@ -179,26 +222,30 @@ func (s *Store) GetMissRunDB() tmdb.DB {
 // node(key, value) -> working tree
 
 func (s *Store) has(key []byte) bool {
+	s.meterRead(key, nil)
 	return s.merkleTree.Has(key)
 }
 
 func (s *Store) set(key, value []byte) bool {
+	s.recordMerkle(key, value)
 	return s.merkleTree.Set(key, value)
 }
 
 // { working tree || saved tree } -> node(key, value)
 func (s *Store) get(key []byte, committed bool) []byte {
+	var value []byte
 	if !committed {
-		_, value := s.merkleTree.Get(key)
-		return value
+		_, value = s.merkleTree.Get(key)
+	} else {
+		_, value = s.merkleTree.GetVersioned(key, s.merkleVersion)
 	}
-
-	_, value := s.merkleTree.GetVersioned(key, s.merkleVersion)
+	s.meterRead(key, value)
 	return value
 }
 
 // working tree, delete node(key, value)
 func (s *Store) remove(key []byte) ([]byte, bool) {
+	s.recordMerkle(key, nil)
 	return s.merkleTree.Remove(key)
 }
 
@@ -254,6 +301,17 @@ func (s *Store) getImmutableTree(committed bool) (*iavl.ImmutableTree, error) {
 	return imt, nil
 }
 
+// Iterate walks through every key-value pair in the merkle tree, stopping
+// when cb returns true.
+func (s *Store) Iterate(committed bool, cb func(key, value []byte) bool) error {
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return err
+	}
+	imt.Iterate(cb)
+	return nil
+}
+
 // Balance store
 func makeBalanceKey(addr tm.Address) []byte {
 	return append(prefixBalance, addr.Bytes()...)
@@ -342,6 +400,11 @@ func (s *Store) checkValidatorMatch(holder crypto.Address, stake *types.Stake, c
 	if prevHolder != nil && !bytes.Equal(prevHolder, holder) {
 		return code.GetError(code.TxCodePermissionDenied)
 	}
+	// key retired by another holder still traces back to that holder
+	retiredHolder := s.GetHolderByRetiredValidator(stake.Validator.Address(), committed)
+	if retiredHolder != nil && !bytes.Equal(retiredHolder, holder) {
+		return code.GetError(code.TxCodePermissionDenied)
+	}
 	prevStake := s.GetStake(holder, committed)
 	if prevStake != nil &&
 		!bytes.Equal(prevStake.Validator[:], stake.Validator[:]) {
@@ -377,7 +440,7 @@ func (s *Store) checkStakeDeletion(holder crypto.Address, stake *types.Stake, he
 			}
 
 			// check if this is the last stake
-			ts := s.GetTopStakes(2, nil, committed)
+			ts := s.GetTopStakes(2, types.SelfStakeRule{}, nil, committed)
 			if len(ts) == 1 {
 				// requested 2 but got 1. it means this is the last validator.
 				return code.GetError(code.TxCodeLastValidator)
@@ -579,6 +642,7 @@ func (s *Store) UnlockStakes(holder crypto.Address, height int64, committed bool
 	}
 
 	imt.IterateRangeInclusive(start, end, true, func(key []byte, value []byte, version int64) bool {
+		s.meterRead(key, value)
 		stake := new(types.Stake)
 		err := json.Unmarshal(value, stake)
 		if err != nil {
@@ -597,6 +661,56 @@ func (s *Store) UnlockStakes(holder crypto.Address, height int64, committed bool
 	s.SetUnlockedStake(holder, unlocked)
 }
 
+// UnlockStakeEarly releases the stake locked at *height* at once. The
+// released stake goes to the holder's unlocked stake except *penalty*, which
+// is taken away from the stake and left to the caller to handle.
+func (s *Store) UnlockStakeEarly(holder crypto.Address, height int64, penalty types.Currency) error {
+	locked := s.GetLockedStake(holder, height, false)
+	if locked == nil {
+		return code.GetError(code.TxCodeNoStake)
+	}
+	// keep something to release, so that the whole stake never goes to zero
+	// here
+	if !locked.Amount.GreaterThan(&penalty) {
+		return code.GetError(code.TxCodeImproperStakeAmount)
+	}
+	released := new(types.Currency).Set(0)
+	released.Add(&locked.Amount).Sub(&penalty)
+
+	// clean up
+	es := s.GetEffStake(holder, false)
+	if es != nil {
+		before := makeEffStakeKey(es.Amount, holder)
+		exist, err := s.indexEffStake.Has(before)
+		if err != nil {
+			s.logger.Error("Store", "UnlockStakeEarly", err.Error())
+			return code.GetError(code.TxCodeUnknown)
+		}
+		if exist {
+			err := s.indexEffStake.Delete(before)
+			if err != nil {
+				s.logger.Error("Store", "UnlockStakeEarly", err.Error())
+				return code.GetError(code.TxCodeUnknown)
+			}
+		}
+	}
+
+	// update
+	s.remove(makeLockedStakeKey(holder, height))
+	unlocked := s.GetUnlockedStake(holder, false)
+	if unlocked == nil {
+		unlocked = &types.Stake{
+			Validator: locked.Validator,
+			Amount:    *released,
+		}
+	} else {
+		unlocked.Amount.Add(released)
+	}
+
+	// indexes are restored here
+	return s.SetUnlockedStake(holder, unlocked)
+}
+
 func (s *Store) LoosenLockedStakes(committed bool) []abci.Event {
 	events := []abci.Event{}
 
@@ -606,6 +720,7 @@ func (s *Store) LoosenLockedStakes(committed bool) []abci.Event {
 	}
 
 	imt.IterateRangeInclusive(prefixStake, nil, true, func(key []byte, value []byte, version int64) bool {
+		s.meterRead(key, value)
 		if !bytes.HasPrefix(key, prefixStake) {
 			return false
 		}
@@ -726,6 +841,7 @@ func (s *Store) GetLockedStakes(holder crypto.Address, committed bool) []*types.
 	}
 
 	imt.IterateRangeInclusive(start, nil, false, func(key []byte, value []byte, version int64) bool {
+		s.meterRead(key, value)
 		if !bytes.HasPrefix(key, holderKey) {
 			return false
 		}
@@ -760,6 +876,7 @@ func (s *Store) GetLockedStakesWithHeight(holder crypto.Address, committed bool)
 	}
 
 	imt.IterateRangeInclusive(start, nil, false, func(key []byte, value []byte, version int64) bool {
+		s.meterRead(key, value)
 		if !bytes.HasPrefix(key, holderKey) {
 			return false
 		}
@@ -799,6 +916,80 @@ func (s *Store) GetHolderByValidator(addr crypto.Address, committed bool) []byte
 	return holder
 }
 
+// Retired validator store
+func makeRetiredKey(addr crypto.Address) []byte {
+	return append(prefixRetired, addr...)
+}
+
+// GetHolderByRetiredValidator returns the holder who used to run a validator
+// with the key of *addr* before rotating it to another one.
+func (s *Store) GetHolderByRetiredValidator(addr crypto.Address, committed bool) []byte {
+	holder := s.get(makeRetiredKey(addr), committed)
+	if len(holder) == 0 {
+		return nil
+	}
+	return holder
+}
+
+// RotateValidator replaces the validator key of every stake, locked or not,
+// held by *holder* with *validator*. Amounts and lock-up heights are left
+// untouched. The old key is kept as a retired one so that blocks and
+// evidences still carrying it can be traced back to the holder.
+func (s *Store) RotateValidator(holder crypto.Address, validator ed25519.PubKeyEd25519) error {
+	stake := s.GetStake(holder, false)
+	if stake == nil {
+		return code.GetError(code.TxCodeNoStake)
+	}
+	if bytes.Equal(stake.Validator[:], validator[:]) {
+		return code.GetError(code.TxCodeBadValidator)
+	}
+	if s.GetHolderByValidator(validator.Address(), false) != nil {
+		return code.GetError(code.TxCodePermissionDenied)
+	}
+	retiredHolder := s.GetHolderByRetiredValidator(validator.Address(), false)
+	if retiredHolder != nil && !bytes.Equal(retiredHolder, holder) {
+		return code.GetError(code.TxCodePermissionDenied)
+	}
+	oldValidator := stake.Validator.Address()
+
+	unlocked := s.GetUnlockedStake(holder, false)
+	if unlocked != nil {
+		unlocked.Validator = validator
+		b, err := json.Marshal(unlocked)
+		if err != nil {
+			return code.GetError(code.TxCodeBadParam)
+		}
+		s.set(makeStakeKey(holder), b)
+	}
+	lockedStakes, heights := s.GetLockedStakesWithHeight(holder, false)
+	for i, lockedStake := range lockedStakes {
+		lockedStake.Validator = validator
+		b, err := json.Marshal(lockedStake)
+		if err != nil {
+			return code.GetError(code.TxCodeBadParam)
+		}
+		s.set(makeLockedStakeKey(holder, heights[i]), b)
+	}
+
+	err := s.indexValidator.Delete(oldValidator)
+	if err != nil {
+		s.logger.Error("Store", "RotateValidator", err.Error())
+		return code.GetError(code.TxCodeUnknown)
+	}
+	err = s.indexValidator.Set(validator.Address(), holder)
+	if err != nil {
+		s.logger.Error("Store", "RotateValidator", err.Error())
+		return code.GetError(code.TxCodeUnknown)
+	}
+	s.set(makeRetiredKey(oldValidator), holder)
+	// holder's own retired key gets back in use
+	if retiredHolder != nil {
+		s.remove(makeRetiredKey(validator.Address()))
+	}
+
+	return nil
+}
+
 // Delegate store
 func makeDelegateKey(holder []byte) []byte {
 	return append(prefixDelegate, holder...)
@@ -914,7 +1105,37 @@ func (s *Store) GetEffStake(delegatee crypto.Address, committed bool) *types.Sta
 	return stake
 }
 
-func (s *Store) GetTopStakes(max uint64, peek crypto.Address, committed bool) []*types.Stake {
+// IsEligible checks if *holder*'s own stake meets the minimum self-stake
+// requirement of *rule*.
+func (s *Store) IsEligible(holder crypto.Address, rule types.SelfStakeRule, committed bool) bool {
+	stake := s.GetStake(holder, committed)
+	if stake == nil {
+		return false
+	}
+	es := s.GetEffStake(holder, committed)
+	return isEligible(&stake.Amount, &es.Amount, rule)
+}
+
+func isEligible(self, eff *types.Currency, rule types.SelfStakeRule) bool {
+	if self.LessThan(&rule.Amount) {
+		return false
+	}
+	if rule.Rate > 0 {
+		// self >= eff * rate
+		ef := new(big.Float).SetInt(&eff.Int)
+		rf := new(big.Float).SetFloat64(rule.Rate)
+		ef.Mul(ef, rf)
+		sf := new(big.Float).SetInt(&self.Int)
+		if sf.Cmp(ef) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTopStakes returns the top *max* effective stakes of the validators
+// meeting the minimum self-stake requirement of *rule*.
+func (s *Store) GetTopStakes(max uint64, rule types.SelfStakeRule, peek crypto.Address, committed bool) []*types.Stake {
 	var (
 		stakes []*types.Stake
 		cnt    uint64 = 0
@@ -934,6 +1155,10 @@ func (s *Store) GetTopStakes(max uint64, peek crypto.Address, committed bool) []
 		amount.SetBytes(key[:32])
 		holder := key[32:]
 		stake := s.GetStake(holder, committed)
+		// filter out validators short of self-stake
+		if !isEligible(&stake.Amount, &amount, rule) {
+			continue
+		}
 		stake.Amount = amount // NOTE: effective stake
 		// filter out hibernating validators
 		if s.GetHibernate(stake.Validator.Address(), committed) != nil {
@@ -968,6 +1193,10 @@ func (s *Store) SetDraft(draftID uint32, value *types.Draft) error {
 
 	s.set(makeDraftKey(draftID), b)
 
+	if value.OpenCount == 0 && value.CloseCount == 0 && value.ApplyCount == 0 {
+		s.removeActiveDraft(draftID)
+	}
+
 	return nil
 }
 
@@ -1001,6 +1230,59 @@ func (s *Store) GetDraftForQuery(draftID uint32, committed bool) *types.DraftFor
 	return &draft
 }
 
+// GetActiveDraftIDs returns IDs of the drafts in process, i.e. not applied or
+// dropped yet, in ascending order.
+// NOTE: drafts get listed since protocol v7, where more than one draft can be
+// in process at once.
+func (s *Store) GetActiveDraftIDs(committed bool) []uint32 {
+	b := s.get(activeDraftsKey, committed)
+	if len(b) == 0 {
+		return nil
+	}
+
+	var draftIDs []uint32
+	err := json.Unmarshal(b, &draftIDs)
+	if err != nil {
+		return nil
+	}
+
+	return draftIDs
+}
+
+func (s *Store) setActiveDraftIDs(draftIDs []uint32) {
+	if len(draftIDs) == 0 {
+		s.remove(activeDraftsKey)
+		return
+	}
+	b, _ := json.Marshal(draftIDs)
+	s.set(activeDraftsKey, b)
+}
+
+// AddActiveDraft puts a draft in the list of drafts in process.
+func (s *Store) AddActiveDraft(draftID uint32) {
+	draftIDs := s.GetActiveDraftIDs(false)
+	i := sort.Search(len(draftIDs), func(i int) bool {
+		return draftIDs[i] >= draftID
+	})
+	if i < len(draftIDs) && draftIDs[i] == draftID {
+		return
+	}
+	draftIDs = append(draftIDs, 0)
+	copy(draftIDs[i+1:], draftIDs[i:])
+	draftIDs[i] = draftID
+	s.setActiveDraftIDs(draftIDs)
+}
+
+func (s *Store) removeActiveDraft(draftID uint32) {
+	draftIDs := s.GetActiveDraftIDs(false)
+	for i, id := range draftIDs {
+		if id == draftID {
+			s.setActiveDraftIDs(append(draftIDs[:i], draftIDs[i+1:]...))
+			return
+		}
+	}
+}
+
 func (s *Store) GetLastDraftID() uint32 {
 	lastDraftID := uint32(0)
 	var start, end []byte
@@ -1013,6 +1295,7 @@ func (s *Store) GetLastDraftID() uint32 {
 	end[prefixLen-1] = ';'
 	// iterate in ascending order
 	s.merkleTree.IterateRange(start, end, true, func(k, v []byte) bool {
+		s.meterRead(k, v)
 		lastDraftID = binary.BigEndian.Uint32(k[prefixLen:])
 		return false
 	})
@@ -1020,18 +1303,46 @@ func (s *Store) GetLastDraftID() uint32 {
 	return lastDraftID
 }
 
+// ProcessDraftVotes advances all drafts in process by one block. Drafts are
+// processed in ascending order of their IDs, so when more than one draft get
+// applied in a block, the config of the later proposed one takes precedence.
 func (s *Store) ProcessDraftVotes(
-	latestDraftIDUint uint32,
 	maxValidators uint64,
-	quorumRate, passRate, refundRate float64,
+	rule types.SelfStakeRule,
+	quorumRate, passRate, refundRate, vetoRate float64,
+	committed bool,
+) []abci.Event {
+	events := []abci.Event{}
+
+	for _, draftID := range s.GetActiveDraftIDs(committed) {
+		evs := s.ProcessDraftVotesOf(
+			draftID,
+			maxValidators, rule,
+			quorumRate, passRate, refundRate, vetoRate,
+			committed,
+		)
+		events = append(events, evs...)
+	}
+
+	return events
+}
+
+// ProcessDraftVotesOf advances a single draft by one block. Before protocol
+// v7, only the latest draft is in process.
+func (s *Store) ProcessDraftVotesOf(
+	draftID uint32,
+	maxValidators uint64,
+	rule types.SelfStakeRule,
+	quorumRate, passRate, refundRate, vetoRate float64,
 	committed bool,
 ) []abci.Event {
+	depositShort := false
 	voteJustGotClosed := false
 	applyDraftConfig := false
 	events := []abci.Event{}
 
 	// check if there is a draft in process first
-	draft := s.GetDraft(latestDraftIDUint, committed)
+	draft := s.GetDraft(draftID, committed)
 
 	// ignore non-existing draft
 	if draft == nil {
@@ -1046,6 +1357,12 @@ func (s *Store) ProcessDraftVotes(
 	// decrement draft's open, close, apply counts
 	if draft.OpenCount > 0 && draft.CloseCount > 0 && draft.ApplyCount > 0 {
 		draft.OpenCount -= int64(1)
+		if draft.OpenCount == 0 && draft.MinDeposit != nil &&
+			draft.Deposit.LessThan(draft.MinDeposit) {
+			depositShort = true
+			draft.CloseCount = int64(0)
+			draft.ApplyCount = int64(0)
+		}
 	} else if draft.OpenCount == 0 && draft.CloseCount > 0 && draft.ApplyCount > 0 {
 		draft.CloseCount -= int64(1)
 		if draft.CloseCount == 0 {
@@ -1059,7 +1376,7 @@ func (s *Store) ProcessDraftVotes(
 	}
 
 	// events
-	idJson, _ := json.Marshal(latestDraftIDUint)
+	idJson, _ := json.Marshal(draftID)
 	draftJson, _ := json.Marshal(draft)
 	events = append(events, abci.Event{
 		Type: "draft",
@@ -1069,82 +1386,54 @@ func (s *Store) ProcessDraftVotes(
 		},
 	})
 
+	// if draft failed to collect enough deposit, drop it
+	if depositShort {
+		s.SetDraft(draftID, draft)
+		evs := s.RefundDraftDeposit(draftID, draft, nil, committed)
+		return append(events, evs...)
+	}
+
 	// if draft just gets closed, update draft's tally value and handle deposit
 	if voteJustGotClosed {
-		// calculate draft.TallyQuorum
-		tes := new(types.Currency).Set(0)
-		tss := s.GetTopStakes(maxValidators, nil, committed)
-		for _, ts := range tss {
-			holder := s.GetHolderByValidator(ts.Validator.Address(), committed)
-			es := s.GetEffStake(holder, committed)
-			tes.Add(&es.Amount)
-		}
-
-		// tallyQuorum = totalEffectiveStake * quorumRate
-		tesf := new(big.Float).SetInt(&tes.Int)
-		qrf := new(big.Float).SetFloat64(quorumRate)
-		qf := tesf.Mul(tesf, qrf)
-
-		tallyQuorum := new(types.Currency)
-		qf.Int(&tallyQuorum.Int)
-
-		draft.TallyQuorum = *tallyQuorum
-
-		// calculate vote.Power, draft.TallyApprove and draft.TallyReject
-		pes := s.GetEffStake(draft.Proposer, committed)
-		draft.TallyApprove.Add(&pes.Amount)
-
-		votes := s.GetVotes(latestDraftIDUint, committed)
-		for _, vote := range votes {
-			// if not included in top stakes, ignore and delete vote
-			ts := s.GetTopStakes(maxValidators, vote.Voter, committed)
-			if len(ts) == 0 {
-				s.DeleteVote(latestDraftIDUint, vote.Voter)
-				continue
-			}
-
-			es := s.GetEffStake(vote.Voter, committed)
-
-			// update vote's tally fields
-			if vote.Vote.Approve {
-				draft.TallyApprove.Add(&es.Amount)
-			} else {
-				draft.TallyReject.Add(&es.Amount)
-			}
+		tally := s.TallyDraft(draftID, draft.Proposer, maxValidators, rule,
+			quorumRate, passRate, refundRate, vetoRate,
+			true, committed)
+
+		draft.TallyQuorum = tally.Quorum
+		draft.TallyApprove.Add(&tally.Approve)
+		draft.TallyReject.Add(&tally.Reject)
+		// NOTE: keep drafts having no abstain or veto votes as they were
+		if draft.TallyAbstain != nil || !tally.Abstain.Equals(types.Zero) {
+			draft.TallyAbstain = &tally.Abstain
+		}
+		if draft.TallyVeto != nil || !tally.Veto.Equals(types.Zero) {
+			draft.TallyVeto = &tally.Veto
 		}
 
-		// totalTally = draft.TallyApprove + draft.TallyReject
-		totalTally := new(types.Currency).Set(0)
-		totalTally.Add(&draft.TallyApprove)
-		totalTally.Add(&draft.TallyReject)
-
-		// refund = totalTally * refundRate
-		tesf = new(big.Float).SetInt(&totalTally.Int)
-		rrf := new(big.Float).SetFloat64(refundRate)
-		rf := tesf.Mul(tesf, rrf)
-
-		refund := new(types.Currency)
-		rf.Int(&refund.Int)
-
-		// if draft.TallyApprove > refund
-		if draft.TallyApprove.GreaterThan(refund) {
-			// return deposit to proposer
-			balance := s.GetBalance(draft.Proposer, committed)
-			balance.Add(&draft.Deposit)
-			s.SetBalance(draft.Proposer, balance)
-			// event
-			addressJson, _ := json.Marshal(draft.Proposer)
+		// burn deposit and drop draft
+		if tally.Vetoed {
+			idJson, _ := json.Marshal(draftID)
 			amountJson, _ := json.Marshal(draft.Deposit)
 			events = append(events, abci.Event{
-				Type: "draft_deposit",
+				Type: "draft_veto",
 				Attributes: []kv.Pair{
-					{Key: []byte("address"), Value: addressJson},
+					{Key: []byte("id"), Value: idJson},
 					{Key: []byte("amount"), Value: amountJson},
 				},
 			})
+			draft.ApplyCount = int64(0)
+			s.SetDraft(draftID, draft)
+			s.deleteDraftDeposits(draftID, s.GetDraftDeposits(draftID, committed))
+			return events
+		}
+
+		if tally.Refund {
+			// return deposit to proposer and co-sponsors
+			evs := s.RefundDraftDeposit(draftID, draft, nil, committed)
+			events = append(events, evs...)
 		} else {
 			// distribute deposit to voters
-			votes := s.GetVotes(latestDraftIDUint, committed)
+			votes := s.GetVotes(draftID, committed)
 
 			// distAmount = draft.Deposit / len(votes)
 			df := new(big.Float).SetInt(&draft.Deposit.Int)
@@ -1169,40 +1458,48 @@ func (s *Store) ProcessDraftVotes(
 					},
 				})
 			}
+			s.deleteDraftDeposits(draftID, s.GetDraftDeposits(draftID, committed))
 		}
-		// if draft.TallyQuorum > totalTally, drop draft config
-		if draft.TallyQuorum.GreaterThan(totalTally) {
+		// drop draft config
+		if !tally.Passed {
 			draft.ApplyCount = int64(0)
-			s.SetDraft(latestDraftIDUint, draft)
+			s.SetDraft(draftID, draft)
 			return events
 		}
+	}
 
-		// pass = totalTally * passRate
-		ttf := new(big.Float).SetInt(&totalTally.Int)
-		prf := new(big.Float).SetFloat64(passRate)
-		pf := ttf.Mul(ttf, prf)
+	s.SetDraft(draftID, draft)
 
-		pass := new(types.Currency)
-		pf.Int(&pass.Int)
+	if applyDraftConfig && draft.Kind == types.DraftKindText {
+		return events
+	}
 
-		// if pass > draft.TallyApprove, drop draft config
-		if pass.GreaterThan(&draft.TallyApprove) {
-			draft.ApplyCount = int64(0)
-			s.SetDraft(latestDraftIDUint, draft)
-			return events
-		}
+	if applyDraftConfig && draft.Kind == types.DraftKindSpend {
+		return append(events, s.spendCommunityPool(draftID, draft)...)
 	}
 
-	s.SetDraft(latestDraftIDUint, draft)
+	if applyDraftConfig && draft.Kind == types.DraftKindUpgrade {
+		return append(events, s.scheduleUpgradePlan(draftID, draft)...)
+	}
 
 	if applyDraftConfig {
-		b, err := json.Marshal(draft.Config)
+		var (
+			b   []byte
+			err error
+		)
+		if len(draft.Diff) > 0 {
+			b, err = s.MergeAppConfig(draft.Diff)
+		} else {
+			// draft without diff is the one proposed before v7
+			b, err = draft.Config.MarshalLegacy()
+			if err == nil {
+				s.SetAppConfig(b)
+			}
+		}
 		if err != nil {
 			return events
 		}
 
-		s.SetAppConfig(b)
-
 		// events
 		events = append(events, abci.Event{
 			Type: "config",
@@ -1214,6 +1511,137 @@ func (s *Store) ProcessDraftVotes(
 	return events
 }
 
+// TallyDraft counts the votes on a draft as of now, and tells if the draft
+// would pass when its vote gets closed.
+func (s *Store) TallyDraft(
+	draftID uint32,
+	proposer crypto.Address,
+	maxValidators uint64,
+	rule types.SelfStakeRule,
+	quorumRate, passRate, refundRate, vetoRate float64,
+	prune, committed bool,
+) *types.DraftTally {
+	tally := types.DraftTally{}
+
+	// quorum = totalEffectiveStake * quorumRate
+	tes := new(types.Currency).Set(0)
+	tss := s.GetTopStakes(maxValidators, rule, nil, committed)
+	for _, ts := range tss {
+		holder := s.GetHolderByValidator(ts.Validator.Address(), committed)
+		es := s.GetEffStake(holder, committed)
+		tes.Add(&es.Amount)
+	}
+	tally.Quorum = *mulRate(tes, quorumRate)
+
+	votes := s.tallyDraftVotes(draftID, proposer, maxValidators, rule,
+		prune, committed)
+	tally.Approve = *votes[types.VoteOptionYes]
+	tally.Reject = *votes[types.VoteOptionNo]
+	tally.Abstain = *votes[types.VoteOptionAbstain]
+	tally.Veto = *votes[types.VoteOptionVeto]
+
+	// totalTally = approve + reject + veto
+	totalTally := new(types.Currency).Set(0)
+	totalTally.Add(&tally.Approve)
+	totalTally.Add(&tally.Reject)
+	totalTally.Add(&tally.Veto)
+
+	// abstain votes count toward quorum only
+	tally.Total.Set(0)
+	tally.Total.Add(totalTally)
+	tally.Total.Add(&tally.Abstain)
+
+	tally.Refund = tally.Approve.GreaterThan(mulRate(totalTally, refundRate))
+	// draft passes when it reaches quorum, is not vetoed, and gets enough
+	// approval in order
+	tally.QuorumReached = !tally.Quorum.GreaterThan(&tally.Total)
+	tally.Vetoed = vetoRate > 0 &&
+		tally.Veto.GreaterThan(mulRate(totalTally, vetoRate))
+	tally.Passed = tally.QuorumReached && !tally.Vetoed &&
+		!mulRate(totalTally, passRate).GreaterThan(&tally.Approve)
+
+	return &tally
+}
+
+func mulRate(amount *types.Currency, rate float64) *types.Currency {
+	af := new(big.Float).SetInt(&amount.Int)
+	rf := new(big.Float).SetFloat64(rate)
+	af.Mul(af, rf)
+
+	result := new(types.Currency)
+	af.Int(&result.Int)
+
+	return result
+}
+
+// tallyDraftVotes sums up the effective stakes of the voters by vote option,
+// counting the proposer's one as approval. A delegator's vote overrides its
+// delegatee's for the delegator's share. Votes from neither top stake holders
+// nor their delegators are ignored, and deleted if prune is true.
+func (s *Store) tallyDraftVotes(
+	draftID uint32,
+	proposer crypto.Address,
+	maxValidators uint64,
+	rule types.SelfStakeRule,
+	prune, committed bool,
+) map[string]*types.Currency {
+	tally := map[string]*types.Currency{
+		types.VoteOptionYes:     new(types.Currency).Set(0),
+		types.VoteOptionNo:      new(types.Currency).Set(0),
+		types.VoteOptionAbstain: new(types.Currency).Set(0),
+		types.VoteOptionVeto:    new(types.Currency).Set(0),
+	}
+	isTop := func(holder crypto.Address) bool {
+		return len(s.GetTopStakes(maxValidators, rule, holder, committed)) > 0
+	}
+
+	// delegators' votes first
+	holderVotes := []*types.VoteInfo{}
+	overridden := map[string]*types.Currency{}
+	for _, vote := range s.GetVotes(draftID, committed) {
+		if isTop(vote.Voter) {
+			holderVotes = append(holderVotes, vote)
+			continue
+		}
+		// NOTE: votes without an option were cast before AMOProtocolV7,
+		// when delegators were not allowed to vote.
+		var delegate *types.Delegate
+		if len(vote.Vote.Option) > 0 {
+			delegate = s.GetDelegate(vote.Voter, committed)
+		}
+		if delegate == nil || !isTop(delegate.Delegatee) {
+			if prune {
+				s.DeleteVote(draftID, vote.Voter)
+			}
+			continue
+		}
+		tally[vote.Vote.GetOption()].Add(&delegate.Amount)
+		key := string(delegate.Delegatee)
+		if _, ok := overridden[key]; !ok {
+			overridden[key] = new(types.Currency).Set(0)
+		}
+		overridden[key].Add(&delegate.Amount)
+	}
+
+	// holders' votes for the rest of their effective stakes
+	addHolderVote := func(holder crypto.Address, option string) {
+		es := s.GetEffStake(holder, committed)
+		if es == nil {
+			return
+		}
+		if o, ok := overridden[string(holder)]; ok {
+			es.Amount.Sub(o)
+		}
+		tally[option].Add(&es.Amount)
+	}
+	addHolderVote(proposer, types.VoteOptionYes)
+	for _, vote := range holderVotes {
+		addHolderVote(vote.Voter, vote.Vote.GetOption())
+	}
+
+	return tally
+}
+
 // Vote store
 func makeVoteKey(draftID uint32, voter crypto.Address) []byte {
 	return append(prefixVote, append(ConvIDFromUint(draftID), voter...)...)
@@ -1256,6 +1684,7 @@ func (s *Store) GetVotes(draftID uint32, committed bool) []*types.VoteInfo {
 	}
 
 	imt.IterateRangeInclusive(voteKey, nil, false, func(key []byte, value []byte, version int64) bool {
+		s.meterRead(key, value)
 		if !bytes.HasPrefix(key, voteKey) {
 			return false
 		}
@@ -1283,6 +1712,128 @@ func (s *Store) DeleteVote(draftID uint32, voter crypto.Address) {
 	s.remove(makeVoteKey(draftID, voter))
 }
 
+// Deposit store
+func makeDepositKey(draftID uint32, depositor crypto.Address) []byte {
+	return append(prefixDeposit, append(ConvIDFromUint(draftID), depositor...)...)
+}
+
+// AddDraftDeposit records a deposit added to a draft by a co-sponsor. The
+// proposer's own deposit is not recorded.
+func (s *Store) AddDraftDeposit(draftID uint32, depositor crypto.Address, amount *types.Currency) error {
+	deposit := s.GetDraftDeposit(draftID, depositor, false)
+	deposit.Add(amount)
+
+	b, err := json.Marshal(deposit)
+	if err != nil {
+		return err
+	}
+
+	s.set(makeDepositKey(draftID, depositor), b)
+
+	return nil
+}
+
+func (s *Store) GetDraftDeposit(draftID uint32, depositor crypto.Address, committed bool) *types.Currency {
+	deposit := new(types.Currency).Set(0)
+	b := s.get(makeDepositKey(draftID, depositor), committed)
+	if len(b) == 0 {
+		return deposit
+	}
+	err := json.Unmarshal(b, deposit)
+	if err != nil {
+		return new(types.Currency).Set(0)
+	}
+	return deposit
+}
+
+func (s *Store) GetDraftDeposits(draftID uint32, committed bool) []*types.DepositInfo {
+	depositKey := makeDepositKey(draftID, []byte{})
+
+	var deposits []*types.DepositInfo
+
+	imt, err := s.getImmutableTree(committed)
+	if err != nil {
+		return nil
+	}
+
+	imt.IterateRangeInclusive(depositKey, nil, true, func(key []byte, value []byte, version int64) bool {
+		s.meterRead(key, value)
+		if !bytes.HasPrefix(key, depositKey) {
+			return true
+		}
+
+		var amount types.Currency
+		err := json.Unmarshal(value, &amount)
+		if err != nil {
+			return false
+		}
+
+		deposits = append(deposits, &types.DepositInfo{
+			Depositor: crypto.Address(key[len(depositKey):]),
+			Amount:    amount,
+		})
+
+		return false
+	})
+
+	return deposits
+}
+
+// RefundDraftDeposit returns the deposit of a draft to the proposer and the
+// co-sponsors. The proposer's share gets reduced by penalty, which is burned.
+func (s *Store) RefundDraftDeposit(
+	draftID uint32,
+	draft *types.Draft,
+	penalty *types.Currency,
+	committed bool,
+) []abci.Event {
+	events := []abci.Event{}
+
+	refund := func(address crypto.Address, amount *types.Currency) {
+		balance := s.GetBalance(address, committed)
+		balance.Add(amount)
+		s.SetBalance(address, balance)
+		// event
+		addressJson, _ := json.Marshal(address)
+		amountJson, _ := json.Marshal(amount)
+		events = append(events, abci.Event{
+			Type: "draft_deposit",
+			Attributes: []kv.Pair{
+				{Key: []byte("address"), Value: addressJson},
+				{Key: []byte("amount"), Value: amountJson},
+			},
+		})
+	}
+
+	deposits := s.GetDraftDeposits(draftID, committed)
+
+	// proposer's share = draft.Deposit - sum of co-sponsors' deposits
+	share := new(types.Currency).Set(0)
+	share.Add(&draft.Deposit)
+	for _, deposit := range deposits {
+		share.Sub(&deposit.Amount)
+	}
+	if penalty != nil {
+		share.Sub(penalty)
+	}
+	refund(draft.Proposer, share)
+
+	for _, deposit := range deposits {
+		refund(deposit.Depositor, &deposit.Amount)
+	}
+	s.deleteDraftDeposits(draftID, deposits)
+
+	return events
+}
+
+// deleteDraftDeposits drops the records of co-sponsors' deposits once the
+// deposit of a draft gets refunded, distributed or burned.
+func (s *Store) deleteDraftDeposits(draftID uint32, deposits []*types.DepositInfo) {
+	for _, deposit := range deposits {
+		s.remove(makeDepositKey(draftID, deposit.Depositor))
+	}
+}
+
 // Parcel store
 func makeParcelKey(parcelID []byte) []byte {
 	return append(prefixParcel, parcelID...)
@@ -1369,6 +1920,7 @@ func (s *Store) GetRequests(parcelID []byte, committed bool) []*types.RequestEx
 
 	imt.IterateRangeInclusive(prefixRequestKey, nil, true,
 		func(key []byte, value []byte, version int64) bool {
+			s.meterRead(key, value)
 			if !bytes.HasPrefix(key, prefixRequestKey) {
 				return false
 			}
@@ -1447,6 +1999,7 @@ func (s *Store) GetUsages(parcelID []byte, committed bool) []*types.UsageEx {
 
 	imt.IterateRangeInclusive(prefixUsageKey, nil, true,
 		func(key []byte, value []byte, version int64) bool {
+			s.meterRead(key, value)
 			if !bytes.HasPrefix(key, prefixUsageKey) {
 				return false
 			}
@@ -1474,20 +2027,20 @@ func (s *Store) DeleteUsage(recipient crypto.Address, parcelID []byte) {
 	s.remove(parcelBuyerKey)
 }
 
-func (s *Store) GetValidators(max uint64, committed bool) abci.ValidatorUpdates {
+// GetValidators returns validator updates of the top *max* stakes, with each
+// voting power limited to *maxPowerRate* of the total voting power. Zero
+// maxPowerRate means no limit.
+func (s *Store) GetValidators(max uint64, maxPowerRate float64, rule types.SelfStakeRule, committed bool) abci.ValidatorUpdates {
 	var vals abci.ValidatorUpdates
-	stakes := s.GetTopStakes(max, nil, committed)
-	adjFactor := calcAdjustFactor(stakes)
-	for _, stake := range stakes {
+	powers := s.GetVotingPowers(max, maxPowerRate, rule, committed)
+	for _, power := range powers {
 		key := abci.PubKey{ // TODO
 			Type: "ed25519",
-			Data: stake.Validator[:],
+			Data: power.Validator,
 		}
-		var power big.Int
-		power.Rsh(&stake.Amount.Int, adjFactor)
 		val := abci.ValidatorUpdate{
 			PubKey: key,
-			Power:  power.Int64(),
+			Power:  power.CappedPower,
 		}
 		if val.Power > 0 {
 			vals = append(vals, val)
@@ -1496,6 +2049,33 @@ func (s *Store) GetValidators(max uint64, committed bool) abci.ValidatorUpdates
 	return vals
 }
 
+// GetVotingPowers returns voting powers of the top *max* stakes both before
+// and after applying the voting power cap of *maxPowerRate*.
+func (s *Store) GetVotingPowers(max uint64, maxPowerRate float64, rule types.SelfStakeRule, committed bool) []*types.VotingPower {
+	var (
+		powers []*types.VotingPower
+		raw    []int64
+	)
+	stakes := s.GetTopStakes(max, rule, nil, committed)
+	adjFactor := calcAdjustFactor(stakes)
+	for _, stake := range stakes {
+		var power big.Int
+		power.Rsh(&stake.Amount.Int, adjFactor)
+		holder := s.GetHolderByValidator(stake.Validator.Address(), committed)
+		powers = append(powers, &types.VotingPower{
+			Validator: stake.Validator[:],
+			Holder:    holder,
+			Power:     power.Int64(),
+		})
+		raw = append(raw, power.Int64())
+	}
+	capped := capVotingPowers(raw, maxPowerRate)
+	for i, power := range powers {
+		power.CappedPower = capped[i]
+	}
+	return powers
+}
+
 func (s *Store) SetProtocolVersion(version uint64) error {
 	b, err := json.Marshal(version)
 	if err != nil {
@@ -1520,6 +2100,29 @@ func (s *Store) GetProtocolVersion(committed bool) uint64 {
 	return 0
 }
 
+func (s *Store) SetChainID(chainID string) error {
+	b, err := json.Marshal(chainID)
+	if err != nil {
+		return err
+	}
+	s.set(chainIDKey, b)
+	return nil
+}
+
+// GetChainID returns the chain id recorded since protocol v7, or an empty
+// string for a chain not yet upgraded to v7.
+func (s *Store) GetChainID(committed bool) string {
+	b := s.get(chainIDKey, committed)
+	if len(b) > 0 {
+		var chainID string
+		err := json.Unmarshal(b, &chainID)
+		if err == nil {
+			return chainID
+		}
+	}
+	return ""
+}
+
 func (s *Store) RebuildIndex() {
 	purgeDB(s.indexDelegator)
 	purgeDB(s.indexValidator)
@@ -1606,6 +2209,65 @@ func calcAdjustFactor(stakes []*types.Stake) uint {
 	return shifts
 }
 
+// capVotingPowers cuts down the voting powers over a cap, where the cap is
+// chosen to be *maxRate* of the total voting power after cutting down. When
+// the cap cannot be met even by equal powers, i.e. maxRate * len(powers) <= 1,
+// powers are left as they are.
+func capVotingPowers(powers []int64, maxRate float64) []int64 {
+	capped := make([]int64, len(powers))
+	copy(capped, powers)
+
+	rf := new(big.Float).SetFloat64(maxRate)
+	nf := new(big.Float).SetInt64(int64(len(powers)))
+	nf.Mul(nf, rf)
+	if maxRate <= 0 || nf.Cmp(big.NewFloat(1)) <= 0 {
+		return capped
+	}
+
+	sorted := make([]int64, len(powers))
+	copy(sorted, powers)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+
+	// rest = sum of powers not capped
+	var rest int64
+	for _, p := range sorted {
+		rest += p
+	}
+
+	// try capping top k powers: cap = maxRate * rest / (1 - maxRate * k)
+	var limit int64 = -1
+	for k := 0; k < len(sorted); k++ {
+		df := new(big.Float).SetInt64(int64(k))
+		df.Mul(df, rf)
+		df.Sub(big.NewFloat(1), df)
+		if df.Sign() <= 0 {
+			limit = sorted[k]
+			break
+		}
+		cf := new(big.Float).SetInt64(rest)
+		cf.Mul(cf, rf)
+		cf.Quo(cf, df)
+		c, _ := cf.Int64()
+		if sorted[k] <= c {
+			if k > 0 {
+				limit = c
+			}
+			break
+		}
+		rest -= sorted[k]
+	}
+	if limit < 0 {
+		return capped
+	}
+
+	for i, p := range capped {
+		if p > limit {
+			capped[i] = limit
+		}
+	}
+	return capped
+}
+
 func cloneDB(dst tmdb.DB, src tmdb.DB) {
 	purgeDB(dst)
 	b := dst.NewBatch()
@@ -361,6 +361,224 @@ func TestLockedStake(t *testing.T) {
 	assert.Equal(t, stake12, stake)
 }
 
+func TestRotateValidator(t *testing.T) {
+	// setup
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	holder1 := makeAccAddr("holder1")
+	holder2 := makeAccAddr("holder2")
+	val1 := makeValAddr("val1")
+	val2 := makeValAddr("val2")
+	stake1 := makeStake("val1", 100)
+	stake2 := makeStake("val2", 100)
+	stake3 := makeStake("val3", 100)
+
+	err = s.RotateValidator(holder1, stake2.Validator)
+	assert.Equal(t, code.GetError(code.TxCodeNoStake), err)
+
+	err = s.SetUnlockedStake(holder1, stake1)
+	assert.NoError(t, err)
+	err = s.SetLockedStake(holder1, stake1, 10)
+	assert.NoError(t, err)
+	err = s.SetUnlockedStake(holder2, stake3)
+	assert.NoError(t, err)
+
+	err = s.RotateValidator(holder1, stake1.Validator)
+	assert.Equal(t, code.GetError(code.TxCodeBadValidator), err)
+	err = s.RotateValidator(holder1, stake3.Validator)
+	assert.Equal(t, code.GetError(code.TxCodePermissionDenied), err)
+
+	err = s.RotateValidator(holder1, stake2.Validator)
+	assert.NoError(t, err)
+
+	stake := s.GetStake(holder1, false)
+	assert.Equal(t, stake2.Validator, stake.Validator)
+	assert.Equal(t, *new(types.Currency).Set(200), stake.Amount)
+	stakes, heights := s.GetLockedStakesWithHeight(holder1, false)
+	assert.Equal(t, 1, len(stakes))
+	assert.Equal(t, stake2, stakes[0])
+	assert.Equal(t, int64(10), heights[0])
+
+	assert.Nil(t, s.GetStakeByValidator(val1, false))
+	assert.Equal(t, stake, s.GetStakeByValidator(val2, false))
+	assert.Equal(t, []byte(holder1), s.GetHolderByRetiredValidator(val1, false))
+	assert.Nil(t, s.GetHolderByRetiredValidator(val2, false))
+
+	// retired key is not available to other holders
+	holder3 := makeAccAddr("holder3")
+	err = s.SetUnlockedStake(holder3, makeStake("val1", 100))
+	assert.Equal(t, code.GetError(code.TxCodePermissionDenied), err)
+	err = s.SetLockedStake(holder3, makeStake("val1", 100), 10)
+	assert.Equal(t, code.GetError(code.TxCodePermissionDenied), err)
+	err = s.RotateValidator(holder2, stake1.Validator)
+	assert.Equal(t, code.GetError(code.TxCodePermissionDenied), err)
+
+	// holder may get back to its own retired key
+	err = s.RotateValidator(holder1, stake1.Validator)
+	assert.NoError(t, err)
+	assert.Nil(t, s.GetHolderByRetiredValidator(val1, false))
+	assert.Equal(t, []byte(holder1), s.GetHolderByRetiredValidator(val2, false))
+	err = s.RotateValidator(holder1, stake2.Validator)
+	assert.NoError(t, err)
+
+	// index should survive rebuilding
+	s.RebuildIndex()
+	assert.Equal(t, []byte(holder1), s.GetHolderByValidator(val2, false))
+	assert.Nil(t, s.GetHolderByValidator(val1, false))
+}
+
+func TestUnlockStakeEarly(t *testing.T) {
+	// setup
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	holder1 := makeAccAddr("holder1")
+	holder2 := makeAccAddr("holder2")
+	val1 := makeValAddr("val1")
+
+	err = s.UnlockStakeEarly(holder1, 10, *new(types.Currency).Set(0))
+	assert.Equal(t, code.GetError(code.TxCodeNoStake), err)
+
+	err = s.SetLockedStake(holder1, makeStake("val1", 100), 10)
+	assert.NoError(t, err)
+	err = s.SetLockedStake(holder2, makeStake("val2", 50), 10)
+	assert.NoError(t, err)
+
+	err = s.UnlockStakeEarly(holder1, 10, *new(types.Currency).Set(100))
+	assert.Equal(t, code.GetError(code.TxCodeImproperStakeAmount), err)
+
+	err = s.UnlockStakeEarly(holder1, 10, *new(types.Currency).Set(30))
+	assert.NoError(t, err)
+
+	assert.Nil(t, s.GetLockedStake(holder1, 10, false))
+	assert.Equal(t, makeStake("val1", 70), s.GetUnlockedStake(holder1, false))
+	assert.Equal(t, makeStake("val1", 70), s.GetStakeByValidator(val1, false))
+
+	// effective stake index must be consistent
+	stakes := s.GetTopStakes(10, types.SelfStakeRule{}, nil, false)
+	assert.Equal(t, 2, len(stakes))
+	assert.Equal(t, *new(types.Currency).Set(70), stakes[0].Amount)
+	assert.Equal(t, *new(types.Currency).Set(50), stakes[1].Amount)
+}
+
+func TestCommunityPool(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	assert.Equal(t, new(types.Currency).Set(0), s.GetCommunityPool(false))
+	err = s.SetCommunityPool(new(types.Currency).Set(100))
+	assert.NoError(t, err)
+	assert.Equal(t, new(types.Currency).Set(100), s.GetCommunityPool(false))
+	assert.Equal(t, new(types.Currency).Set(0), s.GetCommunityPool(true))
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+	assert.Equal(t, new(types.Currency).Set(100), s.GetCommunityPool(true))
+}
+
+func TestSequence(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	addr := makeAccAddr("sender")
+
+	assert.Equal(t, uint64(0), s.GetSequence(addr, false))
+	err = s.SetSequence(addr, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), s.GetSequence(addr, false))
+	assert.Equal(t, uint64(0), s.GetSequence(addr, true))
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), s.GetSequence(addr, true))
+	assert.Equal(t, uint64(0), s.GetSequence(makeAccAddr("other"), true))
+}
+
+func TestBranch(t *testing.T) {
+	// recent versions not in merkle DB yet
+	s, err := NewStore(nil, 3, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	holder1 := makeAccAddr("holder1")
+	holder2 := makeAccAddr("holder2")
+	stake1 := makeStake("val1", 100)
+	stake2 := makeStake("val2", 100)
+
+	s.SetBalance(holder1, new(types.Currency).Set(100))
+	s.SetUnlockedStake(holder1, stake1)
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+	s.SetBalance(holder1, new(types.Currency).Set(50))
+
+	b, err := s.Branch()
+	assert.NoError(t, err)
+	assert.Equal(t, s.GetMerkleVersion(), b.GetMerkleVersion())
+	assert.Equal(t, new(types.Currency).Set(100), b.GetBalance(holder1, false))
+	assert.Equal(t, []byte(holder1), b.GetHolderByValidator(stake1.Validator.Address(), false))
+
+	b.SetBalance(holder2, new(types.Currency).Set(10))
+	b.SetUnlockedStake(holder2, stake2)
+	assert.Equal(t, new(types.Currency).Set(10), b.GetBalance(holder2, false))
+	assert.Equal(t, []byte(holder2), b.GetHolderByValidator(stake2.Validator.Address(), false))
+	assert.Equal(t, 2, len(b.GetTopStakes(10, types.SelfStakeRule{}, nil, false)))
+
+	// no change in the original store
+	assert.Equal(t, new(types.Currency).Set(50), s.GetBalance(holder1, false))
+	assert.Equal(t, new(types.Currency).Set(0), s.GetBalance(holder2, false))
+	assert.Nil(t, s.GetHolderByValidator(stake2.Validator.Address(), false))
+	assert.Equal(t, 1, len(s.GetTopStakes(10, types.SelfStakeRule{}, nil, false)))
+}
+
+func TestTxMeter(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	holder1 := makeAccAddr("holder1")
+	holder2 := makeAccAddr("holder2")
+	stake1 := makeStake("val1", 100)
+	stake2 := makeStake("val2", 100)
+
+	s.SetBalance(holder1, new(types.Currency).Set(100))
+	s.SetUnlockedStake(holder1, stake1)
+	root := s.Root()
+
+	// no metering out of tx
+	s.GetBalance(holder1, false)
+	assert.Equal(t, Meter{}, s.TxMeter())
+
+	s.BeginTx()
+	s.GetBalance(holder1, false)
+	meter := s.TxMeter()
+	assert.Equal(t, uint64(1), meter.Reads)
+	assert.Equal(t, uint64(0), meter.Writes)
+
+	s.SetBalance(holder1, new(types.Currency).Set(50))
+	s.SetBalance(holder2, new(types.Currency).Set(10))
+	s.SetUnlockedStake(holder2, stake2)
+	meter = s.TxMeter()
+	assert.True(t, meter.Writes > 2)
+	assert.True(t, meter.WriteBytes > 0)
+	assert.True(t, meter.Cost() > 0)
+
+	// visits of index items are metered
+	reads := s.TxMeter().Reads
+	assert.Equal(t, 2, len(s.GetTopStakes(10, types.SelfStakeRule{}, nil, false)))
+	assert.True(t, s.TxMeter().Reads > reads+1)
+
+	s.RevertTx()
+	assert.Equal(t, Meter{}, s.TxMeter())
+	assert.Equal(t, root, s.Root())
+	assert.Equal(t, new(types.Currency).Set(100), s.GetBalance(holder1, false))
+	assert.Equal(t, new(types.Currency).Set(0), s.GetBalance(holder2, false))
+	assert.Nil(t, s.GetHolderByValidator(stake2.Validator.Address(), false))
+	assert.Equal(t, 1, len(s.GetTopStakes(10, types.SelfStakeRule{}, nil, false)))
+
+	// changes are kept when the tx ends normally
+	s.BeginTx()
+	s.SetBalance(holder2, new(types.Currency).Set(10))
+	s.EndTx()
+	assert.Equal(t, new(types.Currency).Set(10), s.GetBalance(holder2, false))
+}
+
 func TestSlashStakes(t *testing.T) {
 	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
 	assert.NoError(t, err)
@@ -487,7 +705,7 @@ func TestDelegate(t *testing.T) {
 	assert.Equal(t, *new(types.Currency).Set(303), es)
 
 	// test effective stake cache
-	ts := s.GetTopStakes(10, nil, false)
+	ts := s.GetTopStakes(10, types.SelfStakeRule{}, nil, false)
 	assert.Equal(t, 1, len(ts))
 	assert.Equal(t, s.GetEffStake(staker, false), ts[0])
 }
@@ -504,22 +722,65 @@ func newStake(amount string) (crypto.Address, *types.Stake) {
 	return holder, &stake
 }
 
+func TestMinSelfStake(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	holder1 := makeAccAddr("holder1")
+	holder2 := makeAccAddr("holder2")
+	delegator := makeAccAddr("delegator")
+	s.SetUnlockedStake(holder1, makeStake("val1", 100))
+	s.SetUnlockedStake(holder2, makeStake("val2", 300))
+	s.SetDelegate(delegator, &types.Delegate{
+		Delegatee: holder1,
+		Amount:    *new(types.Currency).Set(900),
+	})
+
+	// no requirement
+	rule := types.SelfStakeRule{}
+	assert.True(t, s.IsEligible(holder1, rule, false))
+	assert.Equal(t, 2, len(s.GetTopStakes(10, rule, nil, false)))
+
+	// ratio: holder1 has 100 out of 1000
+	rule = types.SelfStakeRule{Rate: 0.2}
+	assert.False(t, s.IsEligible(holder1, rule, false))
+	assert.True(t, s.IsEligible(holder2, rule, false))
+	stakes := s.GetTopStakes(10, rule, nil, false)
+	assert.Equal(t, 1, len(stakes))
+	assert.Equal(t, makeStake("val2", 300), stakes[0])
+	assert.Equal(t, 1, len(s.GetValidators(10, 0, rule, false)))
+
+	// top up
+	s.SetLockedStake(holder1, makeStake("val1", 200), 10)
+	assert.True(t, s.IsEligible(holder1, rule, false))
+	assert.Equal(t, 2, len(s.GetTopStakes(10, rule, nil, false)))
+
+	// absolute amount
+	rule = types.SelfStakeRule{Amount: *new(types.Currency).Set(300)}
+	assert.True(t, s.IsEligible(holder1, rule, false))
+	assert.True(t, s.IsEligible(holder2, rule, false))
+	rule = types.SelfStakeRule{Amount: *new(types.Currency).Set(301)}
+	assert.False(t, s.IsEligible(holder2, rule, false))
+	assert.Equal(t, 0, len(s.GetTopStakes(10, rule, nil, false)))
+	assert.False(t, s.IsEligible(makeAccAddr("nobody"), rule, false))
+}
+
 func TestVotingPowerCalc(t *testing.T) {
 	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
 	assert.NoError(t, err)
 
-	vals := s.GetValidators(100, false)
+	vals := s.GetValidators(100, 0, types.SelfStakeRule{}, false)
 	assert.Equal(t, 0, len(vals))
 
 	s.SetUnlockedStake(newStake("1000000000000000000"))
 	s.SetUnlockedStake(newStake("10000000000000000"))
 	s.SetUnlockedStake(newStake("100000000000000000"))
 
-	vals = s.GetValidators(1, false)
+	vals = s.GetValidators(1, 0, types.SelfStakeRule{}, false)
 	assert.Equal(t, 1, len(vals))
 	assert.Equal(t, int64(500000000000000000), vals[0].Power)
 
-	vals = s.GetValidators(100, false)
+	vals = s.GetValidators(100, 0, types.SelfStakeRule{}, false)
 	assert.Equal(t, 3, len(vals))
 	assert.Equal(t, int64(500000000000000000), vals[0].Power)
 	assert.Equal(t, int64(50000000000000000), vals[1].Power)
@@ -528,11 +789,11 @@ func TestVotingPowerCalc(t *testing.T) {
 	// test voting power adjustment
 	s.Purge()
 	s.SetUnlockedStake(newStake("1152921504606846975")) // 0xfffffffffffffff
-	vals = s.GetValidators(100, false)
+	vals = s.GetValidators(100, 0, types.SelfStakeRule{}, false)
 	assert.Equal(t, int64(0x7ffffffffffffff), vals[0].Power)
 
 	s.SetUnlockedStake(newStake("1"))
-	vals = s.GetValidators(100, false)
+	vals = s.GetValidators(100, 0, types.SelfStakeRule{}, false)
 	// The second staker's power shall be adjusted to be zero,
 	// so it shall not be returned as valid validator.
 	assert.Equal(t, 1, len(vals))
@@ -545,7 +806,7 @@ func TestVotingPowerCalc(t *testing.T) {
 	s.SetUnlockedStake(newStake("10239481297483914839120049"))
 
 	var sum int64
-	vals = s.GetValidators(100, false)
+	vals = s.GetValidators(100, 0, types.SelfStakeRule{}, false)
 	for _, val := range vals {
 		sum += val.Power
 	}
@@ -558,7 +819,7 @@ func TestVotingPowerCalc(t *testing.T) {
 	s.SetUnlockedStake(newStake("10000000000000000000"))
 	s.SetUnlockedStake(newStake("10000000000000000000"))
 	sum = 0
-	vals = s.GetValidators(100, false)
+	vals = s.GetValidators(100, 0, types.SelfStakeRule{}, false)
 	for _, val := range vals {
 		sum += val.Power
 	}
@@ -570,13 +831,57 @@ func TestVotingPowerCalc(t *testing.T) {
 	s.SetUnlockedStake(newStake("1000000000000000000"))
 	s.SetUnlockedStake(newStake("1000000000000000000"))
 	sum = 0
-	vals = s.GetValidators(100, false)
+	vals = s.GetValidators(100, 0, types.SelfStakeRule{}, false)
 	for _, val := range vals {
 		sum += val.Power
 	}
 	assert.True(t, sum <= MaxTotalVotingPower)
 }
 
+func TestVotingPowerCap(t *testing.T) {
+	// no cap
+	assert.Equal(t, []int64{60, 30, 10},
+		capVotingPowers([]int64{60, 30, 10}, 0))
+	// cap cannot be met
+	assert.Equal(t, []int64{60, 30, 10},
+		capVotingPowers([]int64{60, 30, 10}, 0.3))
+	// no need to cap
+	assert.Equal(t, []int64{30, 30, 20, 20},
+		capVotingPowers([]int64{30, 30, 20, 20}, 0.5))
+	// cap one: 50 / (1 - 0.5) * 0.5 = 50
+	assert.Equal(t, []int64{50, 30, 20},
+		capVotingPowers([]int64{100, 30, 20}, 0.5))
+	// cap two: 20 * 0.4 / (1 - 0.4 * 2) = 40
+	assert.Equal(t, []int64{40, 40, 10, 10},
+		capVotingPowers([]int64{100, 80, 10, 10}, 0.4))
+	// order does not matter
+	assert.Equal(t, []int64{10, 40, 10, 40},
+		capVotingPowers([]int64{10, 80, 10, 100}, 0.4))
+
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	holder, stake := newStake("1000")
+	s.SetUnlockedStake(holder, stake)
+	s.SetUnlockedStake(newStake("300"))
+	s.SetUnlockedStake(newStake("200"))
+
+	vals := s.GetValidators(100, 0.5, types.SelfStakeRule{}, false)
+	assert.Equal(t, 3, len(vals))
+	assert.Equal(t, int64(500), vals[0].Power)
+	assert.Equal(t, int64(300), vals[1].Power)
+	assert.Equal(t, int64(200), vals[2].Power)
+
+	powers := s.GetVotingPowers(100, 0.5, types.SelfStakeRule{}, false)
+	assert.Equal(t, 3, len(powers))
+	assert.Equal(t, int64(1000), powers[0].Power)
+	assert.Equal(t, int64(500), powers[0].CappedPower)
+	assert.Equal(t, int64(300), powers[1].Power)
+	assert.Equal(t, int64(300), powers[1].CappedPower)
+	assert.Equal(t, vals[0].PubKey.Data, []byte(powers[0].Validator))
+	assert.Equal(t, holder, powers[0].Holder)
+}
+
 func TestMerkleTree(t *testing.T) {
 	// suppose merkleTree is already defined in Store structure
 	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
@@ -731,3 +1036,294 @@ func TestVote(t *testing.T) {
 
 	assert.Equal(t, 3, len(votesOutput))
 }
+
+func TestConcurrentDrafts(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	proposer := p256.GenPrivKey().PubKey().Address()
+	newDraft := func(txReward uint64, apply int64) *types.Draft {
+		return &types.Draft{
+			Proposer: proposer,
+			Config: types.AMOAppConfig{
+				TxReward: *new(types.Currency).Set(txReward),
+			},
+			OpenCount:  0,
+			CloseCount: 0,
+			ApplyCount: apply,
+		}
+	}
+
+	assert.Equal(t, 0, len(s.GetActiveDraftIDs(false)))
+
+	assert.NoError(t, s.SetDraft(1, newDraft(100, 1)))
+	assert.NoError(t, s.SetDraft(2, newDraft(200, 1)))
+	assert.NoError(t, s.SetDraft(3, newDraft(300, 2)))
+	s.AddActiveDraft(3)
+	s.AddActiveDraft(1)
+	s.AddActiveDraft(2)
+	s.AddActiveDraft(2)
+	assert.NoError(t, s.SetDraft(4, newDraft(400, 0))) // already applied
+	assert.Equal(t, []uint32{1, 2, 3}, s.GetActiveDraftIDs(false))
+
+	// drafts 1 and 2 get applied in the same block; the later one wins
+	evs := s.ProcessDraftVotes(100, types.SelfStakeRule{}, 0.1, 0.7, 0.2, 0.334, false)
+	assert.Equal(t, 3+2, len(evs))
+	assert.Equal(t, []uint32{3}, s.GetActiveDraftIDs(false))
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+	var cfg types.AMOAppConfig
+	assert.NoError(t, json.Unmarshal(s.GetAppConfig(), &cfg))
+	assert.Equal(t, new(types.Currency).Set(200), &cfg.TxReward)
+
+	// list of drafts in process is a part of the merkle tree
+	assert.Equal(t, []uint32{3}, s.GetActiveDraftIDs(true))
+
+	evs = s.ProcessDraftVotes(100, types.SelfStakeRule{}, 0.1, 0.7, 0.2, 0.334, false)
+	assert.Equal(t, 1+1, len(evs))
+	assert.Equal(t, 0, len(s.GetActiveDraftIDs(false)))
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(s.GetAppConfig(), &cfg))
+	assert.Equal(t, new(types.Currency).Set(300), &cfg.TxReward)
+}
+
+func TestDraftDiff(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	s.SetAppConfig([]byte(`{"blk_reward":"0","tx_reward":"0"}`))
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+
+	proposer := p256.GenPrivKey().PubKey().Address()
+	assert.NoError(t, s.SetDraft(1, &types.Draft{
+		Proposer:   proposer,
+		Diff:       []byte(`{"blk_reward":"100"}`),
+		ApplyCount: 1,
+	}))
+	s.AddActiveDraft(1)
+	assert.NoError(t, s.SetDraft(2, &types.Draft{
+		Proposer:   proposer,
+		Diff:       []byte(`{"tx_reward":"200"}`),
+		ApplyCount: 1,
+	}))
+	s.AddActiveDraft(2)
+
+	s.ProcessDraftVotes(100, types.SelfStakeRule{}, 0.1, 0.7, 0.2, 0.334, false)
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{"blk_reward":"100","tx_reward":"200"}`,
+		string(s.GetAppConfig()))
+}
+
+func TestDraftKinds(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	cfgRaw := []byte(`{"blk_reward":"0"}`)
+	s.SetAppConfig(cfgRaw)
+	s.SetCommunityPool(new(types.Currency).Set(1000))
+
+	proposer := p256.GenPrivKey().PubKey().Address()
+	recipient := p256.GenPrivKey().PubKey().Address()
+	assert.NoError(t, s.SetDraft(1, &types.Draft{
+		Proposer:   proposer,
+		Kind:       types.DraftKindText,
+		ApplyCount: 1,
+	}))
+	s.AddActiveDraft(1)
+	assert.NoError(t, s.SetDraft(2, &types.Draft{
+		Proposer:   proposer,
+		Kind:       types.DraftKindSpend,
+		Recipient:  recipient,
+		Amount:     new(types.Currency).Set(700),
+		ApplyCount: 1,
+	}))
+	s.AddActiveDraft(2)
+	// pool runs short
+	assert.NoError(t, s.SetDraft(3, &types.Draft{
+		Proposer:   proposer,
+		Kind:       types.DraftKindSpend,
+		Recipient:  recipient,
+		Amount:     new(types.Currency).Set(700),
+		ApplyCount: 1,
+	}))
+	s.AddActiveDraft(3)
+
+	evs := s.ProcessDraftVotes(100, types.SelfStakeRule{}, 0.1, 0.7, 0.2, 0.334, false)
+	assert.Equal(t, 3+1, len(evs))
+	assert.Equal(t, "draft_spend", evs[2].Type)
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+
+	assert.Equal(t, cfgRaw, s.GetAppConfig())
+	assert.Equal(t, new(types.Currency).Set(300), s.GetCommunityPool(true))
+	assert.Equal(t, new(types.Currency).Set(700), s.GetBalance(recipient, true))
+	assert.Equal(t, 0, len(s.GetActiveDraftIDs(false)))
+}
+
+func TestDraftUpgradePlan(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	assert.Nil(t, s.GetUpgradePlan(false))
+
+	plan := &types.UpgradePlan{
+		Name:       "v8",
+		Height:     100,
+		Version:    8,
+		AppVersion: "v1.11.x",
+	}
+	assert.NoError(t, s.SetDraft(1, &types.Draft{
+		Proposer:   makeAccAddr("proposer"),
+		Kind:       types.DraftKindUpgrade,
+		Plan:       plan,
+		ApplyCount: 1,
+	}))
+	s.AddActiveDraft(1)
+
+	evs := s.ProcessDraftVotes(100, types.SelfStakeRule{}, 0.1, 0.7, 0.2, 0.334, false)
+	assert.Equal(t, "upgrade_plan", evs[len(evs)-1].Type)
+	assert.Nil(t, s.GetUpgradePlan(true))
+	assert.Equal(t, plan, s.GetUpgradePlan(false))
+	_, _, err = s.Save()
+	assert.NoError(t, err)
+	assert.Equal(t, plan, s.GetUpgradePlan(true))
+
+	s.DeleteUpgradePlan()
+	assert.Nil(t, s.GetUpgradePlan(false))
+}
+
+func TestDraftVoteOptions(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	proposer := makeAccAddr("proposer")
+	voter1 := makeAccAddr("voter1")
+	voter2 := makeAccAddr("voter2")
+	voter3 := makeAccAddr("voter3")
+	s.SetUnlockedStake(proposer, makeStake("val0", 100))
+	s.SetUnlockedStake(voter1, makeStake("val1", 100))
+	s.SetUnlockedStake(voter2, makeStake("val2", 300))
+	s.SetUnlockedStake(voter3, makeStake("val3", 100))
+
+	newDraft := func() *types.Draft {
+		return &types.Draft{
+			Proposer:   proposer,
+			Kind:       types.DraftKindText,
+			CloseCount: 1,
+			ApplyCount: 1,
+			Deposit:    *new(types.Currency).Set(1000),
+		}
+	}
+
+	// abstain counts toward quorum only
+	s.SetDraft(1, newDraft())
+	s.AddActiveDraft(1)
+	s.SetVote(1, voter1, &types.Vote{Option: types.VoteOptionYes})
+	s.SetVote(1, voter2, &types.Vote{Option: types.VoteOptionAbstain})
+	s.SetVote(1, voter3, &types.Vote{Option: types.VoteOptionNo})
+
+	// veto burns the deposit
+	s.SetDraft(2, newDraft())
+	s.AddActiveDraft(2)
+	s.SetVote(2, voter1, &types.Vote{Option: types.VoteOptionYes})
+	s.SetVote(2, voter2, &types.Vote{Option: types.VoteOptionVeto})
+
+	evs := s.ProcessDraftVotes(100, types.SelfStakeRule{}, 0.6, 0.6, 0.2, 0.334, false)
+
+	draft := s.GetDraft(1, false)
+	assert.Equal(t, new(types.Currency).Set(300), draft.TallyAbstain)
+	assert.Nil(t, draft.TallyVeto)
+	assert.Equal(t, int64(1), draft.ApplyCount)
+
+	draft = s.GetDraft(2, false)
+	assert.Nil(t, draft.TallyAbstain)
+	assert.Equal(t, new(types.Currency).Set(300), draft.TallyVeto)
+	assert.Equal(t, int64(0), draft.ApplyCount)
+	assert.Equal(t, "draft_veto", evs[len(evs)-1].Type)
+
+	// only draft 1 refunds its deposit
+	assert.Equal(t, new(types.Currency).Set(1000), s.GetBalance(proposer, false))
+	assert.Equal(t, types.Zero, s.GetBalance(voter2, false))
+}
+
+func TestDraftDelegatorVotes(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	proposer := makeAccAddr("proposer")
+	validator := makeAccAddr("validator")
+	delegator1 := makeAccAddr("delegator1")
+	delegator2 := makeAccAddr("delegator2")
+	delegator3 := makeAccAddr("delegator3")
+	s.SetUnlockedStake(proposer, makeStake("val0", 100))
+	s.SetUnlockedStake(validator, makeStake("val1", 100))
+	for d, amount := range map[string]uint64{
+		"delegator1": 50, "delegator2": 30, "delegator3": 20,
+	} {
+		s.SetDelegate(makeAccAddr(d), &types.Delegate{
+			Delegatee: validator,
+			Amount:    *new(types.Currency).Set(amount),
+		})
+	}
+
+	s.SetDraft(1, &types.Draft{
+		Proposer:   proposer,
+		Kind:       types.DraftKindText,
+		CloseCount: 1,
+		ApplyCount: 1,
+	})
+	s.AddActiveDraft(1)
+	s.SetVote(1, validator, &types.Vote{Option: types.VoteOptionYes})
+	// overrides validator's vote
+	s.SetVote(1, delegator1, &types.Vote{Option: types.VoteOptionNo})
+	// delegator2 inherits validator's vote
+	// cast before AMOProtocolV7
+	s.SetVote(1, delegator3, &types.Vote{Approve: false})
+
+	s.ProcessDraftVotes(100, types.SelfStakeRule{}, 0.1, 0.7, 0.2, 0.334, false)
+
+	draft := s.GetDraft(1, false)
+	assert.Equal(t, new(types.Currency).Set(100+100+30+20), &draft.TallyApprove)
+	assert.Equal(t, new(types.Currency).Set(50), &draft.TallyReject)
+	assert.NotNil(t, s.GetVote(1, delegator1, false))
+	assert.Nil(t, s.GetVote(1, delegator2, false))
+	assert.Nil(t, s.GetVote(1, delegator3, false))
+}
+
+func TestDraftDepositShort(t *testing.T) {
+	s, err := NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	proposer := makeAccAddr("proposer")
+	sponsor := makeAccAddr("sponsor")
+	s.SetDraft(1, &types.Draft{
+		Proposer:   proposer,
+		Kind:       types.DraftKindText,
+		OpenCount:  1,
+		CloseCount: 1,
+		ApplyCount: 1,
+		Deposit:    *new(types.Currency).Set(700),
+		MinDeposit: new(types.Currency).Set(1000),
+	})
+	s.AddActiveDraft(1)
+	s.AddDraftDeposit(1, sponsor, new(types.Currency).Set(200))
+	s.AddDraftDeposit(1, sponsor, new(types.Currency).Set(100))
+	deposits := s.GetDraftDeposits(1, false)
+	assert.Equal(t, 1, len(deposits))
+	assert.Equal(t, sponsor, deposits[0].Depositor)
+
+	evs := s.ProcessDraftVotes(100, types.SelfStakeRule{}, 0.1, 0.7, 0.2, 0.334, false)
+	assert.Equal(t, 1+2, len(evs))
+
+	draft := s.GetDraft(1, false)
+	assert.Equal(t, int64(0), draft.CloseCount)
+	assert.Equal(t, int64(0), draft.ApplyCount)
+	assert.Equal(t, 0, len(s.GetActiveDraftIDs(false)))
+	assert.Equal(t, new(types.Currency).Set(400), s.GetBalance(proposer, false))
+	assert.Equal(t, new(types.Currency).Set(300), s.GetBalance(sponsor, false))
+	assert.Equal(t, 0, len(s.GetDraftDeposits(1, false)))
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+var (
+	keyUpgradePlan = []byte("upgrade_plan")
+)
+
+func (s Store) SetUpgradePlan(plan *types.UpgradePlan) error {
+	b, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("Invalid upgrade plan")
+	}
+
+	s.set(keyUpgradePlan, b)
+
+	return nil
+}
+
+func (s Store) GetUpgradePlan(committed bool) *types.UpgradePlan {
+	b := s.get(keyUpgradePlan, committed)
+	if len(b) == 0 {
+		return nil
+	}
+	var plan types.UpgradePlan
+	err := json.Unmarshal(b, &plan)
+	if err != nil {
+		return nil
+	}
+	return &plan
+}
+
+func (s Store) DeleteUpgradePlan() {
+	s.remove(keyUpgradePlan)
+}
+
+// scheduleUpgradePlan sets the plan of a passed upgrade draft, replacing any
+// plan scheduled before.
+func (s Store) scheduleUpgradePlan(draftID uint32, draft *types.Draft) []abci.Event {
+	events := []abci.Event{}
+
+	if draft.Plan == nil {
+		return events
+	}
+	if err := s.SetUpgradePlan(draft.Plan); err != nil {
+		return events
+	}
+
+	idJson, _ := json.Marshal(draftID)
+	planJson, _ := json.Marshal(draft.Plan)
+	events = append(events, abci.Event{
+		Type: "upgrade_plan",
+		Attributes: []kv.Pair{
+			{Key: []byte("id"), Value: idJson},
+			{Key: []byte("plan"), Value: planJson},
+		},
+	})
+
+	return events
+}
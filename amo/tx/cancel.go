@@ -34,7 +34,7 @@ type TxCancel struct {
 var _ Tx = &TxCancel{}
 
 func (t *TxCancel) Check() (uint32, string) {
-	txParam, err := parseCancelParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -48,7 +48,7 @@ func (t *TxCancel) Check() (uint32, string) {
 }
 
 func (t *TxCancel) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseCancelParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
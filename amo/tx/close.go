@@ -32,7 +32,7 @@ var _ Tx = &TxClose{}
 
 func (t *TxClose) Check() (uint32, string) {
 	// TOOD: check url format
-	_, err := parseCloseParam(t.getPayload())
+	err := t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -0,0 +1,190 @@
+package tx
+
+// constructors of the tx types, out of which each protocol version builds its
+// tx table
+
+func NewTxTransfer(base TxBase) Tx {
+	param, err := parseTransferParam(base.Payload)
+	base.paramErr = err
+	return &TxTransfer{TxBase: base, Param: param}
+}
+
+func NewTxStake(base TxBase) Tx {
+	param, err := parseStakeParam(base.Payload)
+	base.paramErr = err
+	return &TxStake{TxBase: base, Param: param}
+}
+
+func NewTxWithdraw(base TxBase) Tx {
+	param, err := parseWithdrawParam(base.Payload)
+	base.paramErr = err
+	return &TxWithdraw{TxBase: base, Param: param}
+}
+
+func NewTxDelegate(base TxBase) Tx {
+	param, err := parseDelegateParam(base.Payload)
+	base.paramErr = err
+	return &TxDelegate{TxBase: base, Param: param}
+}
+
+func NewTxRetract(base TxBase) Tx {
+	param, err := parseRetractParam(base.Payload)
+	base.paramErr = err
+	return &TxRetract{TxBase: base, Param: param}
+}
+
+func NewTxSetup(base TxBase) Tx {
+	param, err := parseSetupParam(base.Payload)
+	base.paramErr = err
+	return &TxSetup{TxBase: base, Param: param}
+}
+
+func NewTxClose(base TxBase) Tx {
+	param, err := parseCloseParam(base.Payload)
+	base.paramErr = err
+	return &TxClose{TxBase: base, Param: param}
+}
+
+func NewTxRegister(base TxBase) Tx {
+	param, err := parseRegisterParam(base.Payload)
+	base.paramErr = err
+	return &TxRegister{TxBase: base, Param: param}
+}
+
+func NewTxDiscard(base TxBase) Tx {
+	param, err := parseDiscardParam(base.Payload)
+	base.paramErr = err
+	return &TxDiscard{TxBase: base, Param: param}
+}
+
+func NewTxRequest(base TxBase) Tx {
+	param, err := parseRequestParam(base.Payload)
+	base.paramErr = err
+	return &TxRequest{TxBase: base, Param: param}
+}
+
+func NewTxCancel(base TxBase) Tx {
+	param, err := parseCancelParam(base.Payload)
+	base.paramErr = err
+	return &TxCancel{TxBase: base, Param: param}
+}
+
+func NewTxGrant(base TxBase) Tx {
+	param, err := parseGrantParam(base.Payload)
+	base.paramErr = err
+	return &TxGrant{TxBase: base, Param: param}
+}
+
+func NewTxRevoke(base TxBase) Tx {
+	param, err := parseRevokeParam(base.Payload)
+	base.paramErr = err
+	return &TxRevoke{TxBase: base, Param: param}
+}
+
+func NewTxClaim(base TxBase) Tx {
+	param, err := parseClaimParam(base.Payload)
+	base.paramErr = err
+	return &TxClaim{TxBase: base, Param: param}
+}
+
+func NewTxDismiss(base TxBase) Tx {
+	param, err := parseDismissParam(base.Payload)
+	base.paramErr = err
+	return &TxDismiss{TxBase: base, Param: param}
+}
+
+func NewTxIssue(base TxBase) Tx {
+	param, err := parseIssueParam(base.Payload)
+	base.paramErr = err
+	return &TxIssue{TxBase: base, Param: param}
+}
+
+func NewTxPropose(base TxBase) Tx {
+	param, err := parseProposeParam(base.Payload)
+	base.paramErr = err
+	return &TxPropose{TxBase: base, Param: param}
+}
+
+func NewTxVote(base TxBase) Tx {
+	param, err := parseVoteParam(base.Payload)
+	base.paramErr = err
+	return &TxVote{TxBase: base, Param: param}
+}
+
+func NewTxLock(base TxBase) Tx {
+	param, err := parseLockParam(base.Payload)
+	base.paramErr = err
+	return &TxLock{TxBase: base, Param: param}
+}
+
+func NewTxBurn(base TxBase) Tx {
+	param, err := parseBurnParam(base.Payload)
+	base.paramErr = err
+	return &TxBurn{TxBase: base, Param: param}
+}
+
+func NewTxTransferV5(base TxBase) Tx {
+	param, err := parseTransferParamV5(base.Payload)
+	base.paramErr = err
+	return &TxTransferV5{TxBase: base, Param: param}
+}
+
+func NewTxDIDClaim(base TxBase) Tx {
+	param, err := parseDIDClaimParam(base.Payload)
+	base.paramErr = err
+	return &TxDIDClaim{TxBase: base, Param: param}
+}
+
+func NewTxDIDDismiss(base TxBase) Tx {
+	param, err := parseDIDDismissParam(base.Payload)
+	base.paramErr = err
+	return &TxDIDDismiss{TxBase: base, Param: param}
+}
+
+func NewTxDIDIssue(base TxBase) Tx {
+	param, err := parseDIDIssueParam(base.Payload)
+	base.paramErr = err
+	return &TxDIDIssue{TxBase: base, Param: param}
+}
+
+func NewTxDIDRevoke(base TxBase) Tx {
+	param, err := parseDIDRevokeParam(base.Payload)
+	base.paramErr = err
+	return &TxDIDRevoke{TxBase: base, Param: param}
+}
+
+func NewTxProposeV7(base TxBase) Tx {
+	param, err := parseProposeParam(base.Payload)
+	base.paramErr = err
+	return &TxProposeV7{TxBase: base, Param: param}
+}
+
+func NewTxVoteV7(base TxBase) Tx {
+	param, err := parseVoteParam(base.Payload)
+	base.paramErr = err
+	return &TxVoteV7{TxBase: base, Param: param}
+}
+
+func NewTxRotateValidator(base TxBase) Tx {
+	param, err := parseRotateValidatorParam(base.Payload)
+	base.paramErr = err
+	return &TxRotateValidator{TxBase: base, Param: param}
+}
+
+func NewTxUnlockEarly(base TxBase) Tx {
+	param, err := parseUnlockEarlyParam(base.Payload)
+	base.paramErr = err
+	return &TxUnlockEarly{TxBase: base, Param: param}
+}
+
+func NewTxDepositDraft(base TxBase) Tx {
+	param, err := parseDepositDraftParam(base.Payload)
+	base.paramErr = err
+	return &TxDepositDraft{TxBase: base, Param: param}
+}
+
+func NewTxWithdrawDraft(base TxBase) Tx {
+	param, err := parseWithdrawDraftParam(base.Payload)
+	base.paramErr = err
+	return &TxWithdrawDraft{TxBase: base, Param: param}
+}
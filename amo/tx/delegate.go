@@ -34,7 +34,7 @@ type TxDelegate struct {
 var _ Tx = &TxDelegate{}
 
 func (t *TxDelegate) Check() (uint32, string) {
-	txParam, err := parseDelegateParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -49,7 +49,7 @@ func (t *TxDelegate) Check() (uint32, string) {
 }
 
 func (t *TxDelegate) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseDelegateParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -0,0 +1,97 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type DepositDraftParam struct {
+	DraftID uint32         `json:"draft_id"`
+	Amount  types.Currency `json:"amount"`
+}
+
+func parseDepositDraftParam(raw []byte) (DepositDraftParam, error) {
+	var param DepositDraftParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+// TxDepositDraft adds a deposit to a draft before its vote opens, so that
+// anyone can co-sponsor the draft. The proposer may add to its own deposit.
+type TxDepositDraft struct {
+	TxBase
+	Param DepositDraftParam `json:"-"`
+}
+
+var _ Tx = &TxDepositDraft{}
+
+func (t *TxDepositDraft) Check() (uint32, string) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if !txParam.Amount.GreaterThan(types.Zero) {
+		return code.TxCodeInvalidAmount, "invalid amount"
+	}
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxDepositDraft) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error(), nil
+	}
+	if !txParam.Amount.GreaterThan(types.Zero) {
+		return code.TxCodeInvalidAmount, "invalid amount", nil
+	}
+
+	draft := store.GetDraft(txParam.DraftID, false)
+	if draft == nil {
+		return code.TxCodeNonExistingDraft, "non-existing draft", nil
+	}
+
+	if !(draft.OpenCount > 0 &&
+		draft.CloseCount > 0 &&
+		draft.ApplyCount > 0) {
+		return code.TxCodeVoteNotOpen, "deposit period is over", nil
+	}
+
+	balance := store.GetBalance(t.GetSender(), false)
+	if balance.LessThan(&txParam.Amount) {
+		return code.TxCodeNotEnoughBalance, "not enough balance", nil
+	}
+	balance.Sub(&txParam.Amount)
+
+	draft.Deposit.Add(&txParam.Amount)
+	store.SetDraft(txParam.DraftID, draft)
+	// proposer's deposit goes to its own share, which is subject to penalty
+	if !bytes.Equal(t.GetSender(), draft.Proposer) {
+		store.AddDraftDeposit(txParam.DraftID, t.GetSender(), &txParam.Amount)
+	}
+	store.SetBalance(t.GetSender(), balance)
+
+	// event
+	idJson, _ := json.Marshal(txParam.DraftID)
+	draftJson, _ := json.Marshal(draft)
+	events := []abci.Event{
+		{
+			Type: "draft",
+			Attributes: []kv.Pair{
+				{Key: []byte("id"), Value: idJson},
+				{Key: []byte("draft"), Value: draftJson},
+			},
+		},
+	}
+
+	return code.TxCodeOK, "ok", events
+}
@@ -0,0 +1,108 @@
+package tx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func TestDepositDraft(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	ConfigAMOApp, err = types.NewDefaultAMOAppConfig()
+	assert.NoError(t, err)
+	ConfigAMOApp.DraftDeposit = *new(types.Currency).Set(1000)
+	ConfigAMOApp.DraftInitialDepositRate = 0.3
+
+	var k ed25519.PubKeyEd25519
+	copy(k[:], tmrand.Bytes(32))
+	assert.NoError(t, s.SetUnlockedStake(makeAccAddr("proposer"), &types.Stake{
+		Validator: k,
+		Amount:    *new(types.Currency).Set(10000000),
+	}))
+	s.SetBalance(makeAccAddr("proposer"), new(types.Currency).Set(1000))
+	s.SetBalance(makeAccAddr("sponsor"), new(types.Currency).Set(1000))
+
+	// propose with a partial deposit
+	StateNextDraftID = 1
+	payload, _ := json.Marshal(ProposeParam{
+		DraftID: 1,
+		Desc:    "co-sponsor me",
+		Deposit: new(types.Currency).Set(200),
+	})
+	rc, _, _ := makeTestTxV7("propose", "proposer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeInvalidAmount, rc)
+	payload, _ = json.Marshal(ProposeParam{
+		DraftID: 1,
+		Desc:    "co-sponsor me",
+		Deposit: new(types.Currency).Set(400),
+	})
+	rc, _, _ = makeTestTxV7("propose", "proposer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, new(types.Currency).Set(600),
+		s.GetBalance(makeAccAddr("proposer"), false))
+
+	// co-sponsor
+	payload, _ = json.Marshal(DepositDraftParam{
+		DraftID: 1,
+		Amount:  *new(types.Currency).Set(0),
+	})
+	tx := makeTestTxV7("deposit_draft", "sponsor", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeInvalidAmount, rc)
+
+	payload, _ = json.Marshal(DepositDraftParam{
+		DraftID: 2,
+		Amount:  *new(types.Currency).Set(300),
+	})
+	rc, _, _ = makeTestTxV7("deposit_draft", "sponsor", payload).Execute(s)
+	assert.Equal(t, code.TxCodeNonExistingDraft, rc)
+
+	payload, _ = json.Marshal(DepositDraftParam{
+		DraftID: 1,
+		Amount:  *new(types.Currency).Set(300),
+	})
+	tx = makeTestTxV7("deposit_draft", "sponsor", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	draft := s.GetDraft(1, false)
+	assert.Equal(t, new(types.Currency).Set(1000), &draft.Deposit)
+	assert.Equal(t, new(types.Currency).Set(1000), draft.MinDeposit)
+	assert.Equal(t, new(types.Currency).Set(600),
+		s.GetDraftDeposit(1, makeAccAddr("sponsor"), false))
+	assert.Equal(t, new(types.Currency).Set(400),
+		s.GetBalance(makeAccAddr("sponsor"), false))
+
+	// proposer's deposit is not recorded as a co-sponsor's one
+	payload, _ = json.Marshal(DepositDraftParam{
+		DraftID: 1,
+		Amount:  *new(types.Currency).Set(100),
+	})
+	rc, _, _ = makeTestTxV7("deposit_draft", "proposer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	draft = s.GetDraft(1, false)
+	assert.Equal(t, new(types.Currency).Set(1100), &draft.Deposit)
+	assert.Equal(t, 1, len(s.GetDraftDeposits(1, false)))
+	assert.Equal(t, new(types.Currency).Set(500),
+		s.GetBalance(makeAccAddr("proposer"), false))
+
+	// vote opened
+	draft.OpenCount = 0
+	s.SetDraft(1, draft)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeVoteNotOpen, rc)
+}
@@ -36,7 +36,7 @@ type TxClaim struct {
 var _ Tx = &TxClaim{}
 
 func (t *TxClaim) Check() (uint32, string) {
-	_, err := parseClaimParam(t.getPayload())
+	err := t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -45,7 +45,7 @@ func (t *TxClaim) Check() (uint32, string) {
 }
 
 func (t *TxClaim) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseClaimParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -82,7 +82,7 @@ type TxDismiss struct {
 var _ Tx = &TxDismiss{}
 
 func (t *TxDismiss) Check() (uint32, string) {
-	_, err := parseDismissParam(t.getPayload())
+	err := t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
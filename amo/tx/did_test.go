@@ -22,7 +22,7 @@ func makeTestTxV6(txType string, seed string, payload []byte) Tx {
 		Payload: payload,
 	}
 	trans.Sign(privKey)
-	return classifyTxV6(trans)
+	return testTxRegistryV6.Classify(trans)
 }
 
 // legacy
@@ -61,7 +61,7 @@ type TxDIDClaim struct {
 var _ Tx = &TxDIDClaim{}
 
 func (t *TxDIDClaim) Check() (uint32, string) {
-	param, err := parseDIDClaimParam(t.getPayload())
+	param, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -106,7 +106,7 @@ func (t *TxDIDClaim) Check() (uint32, string) {
 }
 
 func (t *TxDIDClaim) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseDIDClaimParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -166,7 +166,7 @@ type TxDIDDismiss struct {
 var _ Tx = &TxDIDDismiss{}
 
 func (t *TxDIDDismiss) Check() (uint32, string) {
-	param, err := parseDIDDismissParam(t.getPayload())
+	param, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -241,7 +241,7 @@ type TxDIDIssue struct {
 }
 
 func (t *TxDIDIssue) Check() (uint32, string) {
-	param, err := parseDIDIssueParam(t.getPayload())
+	param, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -275,7 +275,7 @@ func (t *TxDIDIssue) Check() (uint32, string) {
 }
 
 func (t *TxDIDIssue) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	param, err := parseDIDIssueParam(t.getPayload())
+	param, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -337,7 +337,7 @@ type TxDIDRevoke struct {
 }
 
 func (t *TxDIDRevoke) Check() (uint32, string) {
-	param, err := parseDIDRevokeParam(t.getPayload())
+	param, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -33,8 +33,8 @@ var _ Tx = &TxDiscard{}
 
 func (t *TxDiscard) Check() (uint32, string) {
 	// TOOD: check format
-	//txParam, err := parseDiscardParam(t.getPayload())
-	_, err := parseDiscardParam(t.getPayload())
+	//txParam, err := t.Param, t.paramErr
+	err := t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -43,7 +43,7 @@ func (t *TxDiscard) Check() (uint32, string) {
 }
 
 func (t *TxDiscard) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseDiscardParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -0,0 +1,16 @@
+package tx
+
+import (
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+// MinFee returns the minimum fee of the tx costing the execution cost given
+// under the fee schedule of the config, which is zero when the tx type has no
+// minimum fee.
+func MinFee(cfg *types.AMOAppConfig, t Tx, cost uint64) *types.Currency {
+	rate, ok := cfg.MinFees[t.GetType()]
+	if !ok {
+		return new(types.Currency).Set(0)
+	}
+	return rate.Fee(len(t.getPayload()), cost)
+}
@@ -38,7 +38,7 @@ type TxGrant struct {
 var _ Tx = &TxGrant{}
 
 func (t *TxGrant) Check() (uint32, string) {
-	txParam, err := parseGrantParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -53,7 +53,7 @@ func (t *TxGrant) Check() (uint32, string) {
 }
 
 func (t *TxGrant) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseGrantParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -57,7 +57,8 @@ func (t *TxIssue) Execute(s *store.Store) (uint32, string, []abci.Event) {
 
 	udc := s.GetUDC(param.UDC, false)
 	if udc == nil {
-		stakes := s.GetTopStakes(ConfigAMOApp.MaxValidators, sender, false)
+		stakes := s.GetTopStakes(ConfigAMOApp.MaxValidators,
+			ConfigAMOApp.SelfStakeRule(), sender, false)
 		if len(stakes) == 0 {
 			return code.TxCodePermissionDenied, "permission denied", nil
 		}
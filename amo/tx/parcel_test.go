@@ -25,7 +25,7 @@ func makeTestTxV5(txType string, seed string, payload []byte) Tx {
 		Payload: payload,
 	}
 	trans.Sign(privKey)
-	return classifyTxV5(trans)
+	return testTxRegistryV5.Classify(trans)
 }
 
 func TestParseTransferV5_coin(t *testing.T) {
@@ -72,7 +72,7 @@ func TestParseTransferV5_coin(t *testing.T) {
 			Amount: *bal,
 		},
 	}
-	parsedTx, err := ParseTxV5(bytes)
+	parsedTx, err := testTxRegistryV5.Parse(bytes)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, parsedTx)
 }
@@ -122,7 +122,7 @@ func TestParseTransferV5_parcel(t *testing.T) {
 			Parcel: parcel,
 		},
 	}
-	parsedTx, err := ParseTxV5(bytes)
+	parsedTx, err := testTxRegistryV5.Parse(bytes)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, parsedTx)
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/libs/kv"
 
 	"github.com/amolabs/amoabci/amo/code"
@@ -15,6 +16,12 @@ type ProposeParam struct {
 	DraftID uint32          `json:"draft_id"`
 	Config  json.RawMessage `json:"config,omitempty"`
 	Desc    string          `json:"desc"`
+	// since AMOProtocolV7
+	Kind      string             `json:"kind,omitempty"`
+	Recipient crypto.Address     `json:"recipient,omitempty"`
+	Amount    *types.Currency    `json:"amount,omitempty"`
+	Deposit   *types.Currency    `json:"deposit,omitempty"`
+	Plan      *types.UpgradePlan `json:"plan,omitempty"`
 }
 
 func parseProposeParam(raw []byte) (ProposeParam, error) {
@@ -34,7 +41,7 @@ type TxPropose struct {
 var _ Tx = &TxPropose{}
 
 func (t *TxPropose) Check() (uint32, string) {
-	_, err := parseProposeParam(t.getPayload())
+	err := t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -42,12 +49,13 @@ func (t *TxPropose) Check() (uint32, string) {
 }
 
 func (t *TxPropose) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseProposeParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
 
-	stakes := store.GetTopStakes(ConfigAMOApp.MaxValidators, t.GetSender(), false)
+	stakes := store.GetTopStakes(ConfigAMOApp.MaxValidators,
+		ConfigAMOApp.SelfStakeRule(), t.GetSender(), false)
 	if len(stakes) == 0 {
 		return code.TxCodePermissionDenied, "no permission to propose a draft", nil
 	}
@@ -0,0 +1,215 @@
+package tx
+
+import (
+	"encoding/json"
+	"math/big"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+// TxProposeV7 allows a new draft while other drafts are in process, as long
+// as the number of drafts in process does not exceed DraftMaxActive. The
+// config in ProposeParam is a partial one listing only the fields to change.
+type TxProposeV7 struct {
+	TxBase
+	Param ProposeParam `json:"-"`
+}
+
+var _ Tx = &TxProposeV7{}
+
+func (t *TxProposeV7) Check() (uint32, string) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	return checkProposeParamV7(txParam)
+}
+
+func checkProposeParamV7(txParam ProposeParam) (uint32, string) {
+	if txParam.Deposit != nil && txParam.Deposit.LessThan(types.Zero) {
+		return code.TxCodeInvalidAmount, "invalid deposit"
+	}
+	switch txParam.Kind {
+	case "", types.DraftKindConfig:
+		if len(txParam.Config) > 0 {
+			_, err := types.CheckConfigDiff(txParam.Config)
+			if err != nil {
+				return code.TxCodeImproperDraftConfig, err.Error()
+			}
+		}
+	case types.DraftKindText:
+		if len(txParam.Config) > 0 {
+			return code.TxCodeBadParam, "config in text draft"
+		}
+	case types.DraftKindSpend:
+		if len(txParam.Config) > 0 {
+			return code.TxCodeBadParam, "config in spend draft"
+		}
+		if len(txParam.Recipient) != crypto.AddressSize {
+			return code.TxCodeBadParam, "improper recipient address"
+		}
+		if txParam.Amount == nil || !txParam.Amount.GreaterThan(types.Zero) {
+			return code.TxCodeInvalidAmount, "invalid amount"
+		}
+	case types.DraftKindUpgrade:
+		if len(txParam.Config) > 0 {
+			return code.TxCodeBadParam, "config in upgrade draft"
+		}
+		plan := txParam.Plan
+		if plan == nil || len(plan.Name) == 0 {
+			return code.TxCodeBadParam, "improper upgrade plan"
+		}
+		if plan.Height <= 0 || plan.Version == 0 {
+			return code.TxCodeBadParam, "improper upgrade plan"
+		}
+	default:
+		return code.TxCodeBadParam, "unknown draft kind"
+	}
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxProposeV7) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error(), nil
+	}
+	if rc, info := checkProposeParamV7(txParam); rc != code.TxCodeOK {
+		return rc, info, nil
+	}
+
+	stakes := store.GetTopStakes(ConfigAMOApp.MaxValidators,
+		ConfigAMOApp.SelfStakeRule(), t.GetSender(), false)
+	if len(stakes) == 0 {
+		return code.TxCodePermissionDenied, "no permission to propose a draft", nil
+	}
+
+	if txParam.DraftID != StateNextDraftID {
+		return code.TxCodeImproperDraftID, "improper draft ID", nil
+	}
+
+	if ConfigAMOApp.DraftMaxActive > 0 &&
+		uint64(len(store.GetActiveDraftIDs(false))) >= ConfigAMOApp.DraftMaxActive {
+		return code.TxCodeAnotherDraftInProcess, "too many drafts in process", nil
+	}
+
+	draft := store.GetDraft(txParam.DraftID, false)
+	if draft != nil {
+		return code.TxCodeProposedDraft, "already proposed draft", nil
+	}
+
+	// proposer may leave the rest of deposit to co-sponsors
+	deposit := new(types.Currency).Set(0).Add(&ConfigAMOApp.DraftDeposit)
+	if txParam.Deposit != nil {
+		minDeposit := new(types.Currency)
+		df := new(big.Float).SetInt(&ConfigAMOApp.DraftDeposit.Int)
+		rf := new(big.Float).SetFloat64(ConfigAMOApp.DraftInitialDepositRate)
+		df.Mul(df, rf).Int(&minDeposit.Int)
+		if txParam.Deposit.LessThan(minDeposit) {
+			return code.TxCodeInvalidAmount, "not enough initial deposit", nil
+		}
+		deposit = txParam.Deposit
+	}
+
+	balance := store.GetBalance(t.GetSender(), false)
+	if balance.LessThan(deposit) {
+		return code.TxCodeNotEnoughBalance, "not enough balance", nil
+	}
+	balance.Sub(deposit)
+
+	kind := txParam.Kind
+	if kind == "" {
+		kind = types.DraftKindConfig
+	}
+	if kind == types.DraftKindConfig && len(txParam.Config) == 0 {
+		// empty config is used to give an opinion
+		kind = types.DraftKindText
+	}
+
+	cfg := ConfigAMOApp
+	var (
+		diff      []byte
+		recipient crypto.Address
+		amount    *types.Currency
+		plan      *types.UpgradePlan
+	)
+	switch kind {
+	case types.DraftKindConfig:
+		cfg, err = ConfigAMOApp.CheckDiff(StateBlockHeight, StateProtocolVersion,
+			txParam.Config)
+		if err != nil {
+			return code.TxCodeImproperDraftConfig, err.Error(), nil
+		}
+		// normalize diff
+		diff, err = types.MergeConfigDiff(nil, txParam.Config)
+		if err != nil {
+			return code.TxCodeImproperDraftConfig, err.Error(), nil
+		}
+	case types.DraftKindSpend:
+		pool := store.GetCommunityPool(false)
+		if pool.LessThan(txParam.Amount) {
+			return code.TxCodeNotEnoughBalance, "not enough community pool", nil
+		}
+		recipient = txParam.Recipient
+		amount = txParam.Amount
+	case types.DraftKindUpgrade:
+		// plan should take effect after the draft gets applied
+		applyHeight := StateBlockHeight + ConfigAMOApp.DraftOpenCount +
+			ConfigAMOApp.DraftCloseCount + ConfigAMOApp.DraftApplyCount
+		if txParam.Plan.Height <= applyHeight {
+			return code.TxCodeImproperDraftConfig,
+				"upgrade height earlier than draft application", nil
+		}
+		if txParam.Plan.Version != StateProtocolVersion+1 {
+			return code.TxCodeImproperDraftConfig,
+				"improper upgrade protocol version", nil
+		}
+		plan = txParam.Plan
+	}
+
+	events := []abci.Event{}
+
+	// set draft
+	newDraft := &types.Draft{
+		Proposer:  t.GetSender(),
+		Kind:      kind,
+		Config:    cfg,
+		Diff:      diff,
+		Recipient: recipient,
+		Amount:    amount,
+		Plan:      plan,
+		Desc:      txParam.Desc,
+
+		OpenCount:  ConfigAMOApp.DraftOpenCount,
+		CloseCount: ConfigAMOApp.DraftCloseCount,
+		ApplyCount: ConfigAMOApp.DraftApplyCount,
+		Deposit:    *deposit,
+		MinDeposit: new(types.Currency).Set(0).Add(&ConfigAMOApp.DraftDeposit),
+
+		TallyQuorum:  *types.Zero,
+		TallyApprove: *types.Zero,
+		TallyReject:  *types.Zero,
+	}
+	store.SetDraft(txParam.DraftID, newDraft)
+	store.AddActiveDraft(txParam.DraftID)
+	// event
+	idJson, _ := json.Marshal(txParam.DraftID)
+	draftJson, _ := json.Marshal(newDraft)
+	events = append(events, abci.Event{
+		Type: "draft",
+		Attributes: []kv.Pair{
+			{Key: []byte("id"), Value: idJson},
+			{Key: []byte("draft"), Value: draftJson},
+		},
+	})
+
+	// set sender balance
+	store.SetBalance(t.GetSender(), balance)
+
+	return code.TxCodeOK, "ok", events
+}
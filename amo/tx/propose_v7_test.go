@@ -0,0 +1,167 @@
+package tx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func TestProposeV7(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	ConfigAMOApp, err = types.NewDefaultAMOAppConfig()
+	assert.NoError(t, err)
+	ConfigAMOApp.DraftDeposit = *new(types.Currency).Set(1000)
+	ConfigAMOApp.DraftMaxActive = uint64(2)
+
+	var k ed25519.PubKeyEd25519
+	copy(k[:], tmrand.Bytes(32))
+	assert.NoError(t, s.SetUnlockedStake(makeAccAddr("proposer"), &types.Stake{
+		Validator: k,
+		Amount:    *new(types.Currency).Set(10000000),
+	}))
+	s.SetBalance(makeAccAddr("proposer"), new(types.Currency).Set(3000))
+
+	propose := func(draftID uint32) uint32 {
+		StateNextDraftID = draftID
+		payload, _ := json.Marshal(ProposeParam{
+			DraftID: draftID,
+			Config:  []byte(`{"min_staking_unit": "100"}`),
+			Desc:    "any json",
+		})
+		t1 := makeTestTxV7("propose", "proposer", payload)
+		rc, _ := t1.Check()
+		assert.Equal(t, code.TxCodeOK, rc)
+		rc, _, _ = t1.Execute(s)
+		return rc
+	}
+
+	// diff out of bounds
+	payload, _ := json.Marshal(ProposeParam{
+		DraftID: uint32(1),
+		Config:  []byte(`{"draft_quorum_rate": 2}`),
+	})
+	rc, _ := makeTestTxV7("propose", "proposer", payload).Check()
+	assert.Equal(t, code.TxCodeImproperDraftConfig, rc)
+
+	// drafts in process side by side
+	assert.Equal(t, code.TxCodeOK, propose(1))
+	assert.Equal(t, code.TxCodeOK, propose(2))
+	assert.Equal(t, []uint32{1, 2}, s.GetActiveDraftIDs(false))
+	draft := s.GetDraft(1, false)
+	assert.Equal(t, `{"min_staking_unit":"100"}`, string(draft.Diff))
+
+	// exceeds DraftMaxActive
+	assert.Equal(t, code.TxCodeAnotherDraftInProcess, propose(3))
+
+	// no limit
+	ConfigAMOApp.DraftMaxActive = 0
+	assert.Equal(t, code.TxCodeOK, propose(3))
+	assert.Equal(t, []uint32{1, 2, 3}, s.GetActiveDraftIDs(false))
+	assert.Equal(t, types.Zero, s.GetBalance(makeAccAddr("proposer"), false))
+
+	// text draft
+	s.SetBalance(makeAccAddr("proposer"), new(types.Currency).Set(1000))
+	payload, _ = json.Marshal(ProposeParam{
+		DraftID: uint32(4),
+		Kind:    types.DraftKindText,
+		Config:  []byte(`{"min_staking_unit": "100"}`),
+	})
+	rc, _ = makeTestTxV7("propose", "proposer", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	StateNextDraftID = 4
+	payload, _ = json.Marshal(ProposeParam{
+		DraftID: uint32(4),
+		Desc:    "let's talk",
+	})
+	rc, _, _ = makeTestTxV7("propose", "proposer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, types.DraftKindText, s.GetDraft(4, false).Kind)
+
+	// spend draft
+	s.SetBalance(makeAccAddr("proposer"), new(types.Currency).Set(1000))
+	payload, _ = json.Marshal(ProposeParam{
+		DraftID: uint32(5),
+		Kind:    types.DraftKindSpend,
+		Amount:  new(types.Currency).Set(500),
+	})
+	rc, _ = makeTestTxV7("propose", "proposer", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	payload, _ = json.Marshal(ProposeParam{
+		DraftID:   uint32(5),
+		Kind:      types.DraftKindSpend,
+		Recipient: makeAccAddr("recipient"),
+		Amount:    new(types.Currency).Set(500),
+	})
+	t1 := makeTestTxV7("propose", "proposer", payload)
+	rc, _ = t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	StateNextDraftID = 5
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeNotEnoughBalance, rc)
+	s.SetCommunityPool(new(types.Currency).Set(500))
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	draft = s.GetDraft(5, false)
+	assert.Equal(t, types.DraftKindSpend, draft.Kind)
+	assert.Equal(t, new(types.Currency).Set(500), draft.Amount)
+
+	// upgrade draft
+	ConfigAMOApp.DraftMaxActive = uint64(0)
+	StateBlockHeight = int64(10)
+	StateProtocolVersion = uint64(0x7)
+	s.SetBalance(makeAccAddr("proposer"), new(types.Currency).Set(1000))
+	payload, _ = json.Marshal(ProposeParam{
+		DraftID: uint32(6),
+		Kind:    types.DraftKindUpgrade,
+	})
+	rc, _ = makeTestTxV7("propose", "proposer", payload).Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+	plan := &types.UpgradePlan{
+		Name:       "v8",
+		Height:     StateBlockHeight + 1,
+		Version:    uint64(0x8),
+		AppVersion: "v1.11.x",
+	}
+	payload, _ = json.Marshal(ProposeParam{
+		DraftID: uint32(6),
+		Kind:    types.DraftKindUpgrade,
+		Plan:    plan,
+	})
+	t1 = makeTestTxV7("propose", "proposer", payload)
+	rc, _ = t1.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	StateNextDraftID = 6
+	rc, _, _ = t1.Execute(s)
+	assert.Equal(t, code.TxCodeImproperDraftConfig, rc)
+	plan.Height = StateBlockHeight + ConfigAMOApp.DraftOpenCount +
+		ConfigAMOApp.DraftCloseCount + ConfigAMOApp.DraftApplyCount + 1
+	plan.Version = uint64(0x9)
+	payload, _ = json.Marshal(ProposeParam{
+		DraftID: uint32(6),
+		Kind:    types.DraftKindUpgrade,
+		Plan:    plan,
+	})
+	rc, _, _ = makeTestTxV7("propose", "proposer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeImproperDraftConfig, rc)
+	plan.Version = uint64(0x8)
+	payload, _ = json.Marshal(ProposeParam{
+		DraftID: uint32(6),
+		Kind:    types.DraftKindUpgrade,
+		Plan:    plan,
+	})
+	rc, _, _ = makeTestTxV7("propose", "proposer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	draft = s.GetDraft(6, false)
+	assert.Equal(t, types.DraftKindUpgrade, draft.Kind)
+	assert.Equal(t, plan, draft.Plan)
+}
@@ -38,7 +38,7 @@ type TxRegister struct {
 var _ Tx = &TxRegister{}
 
 func (t *TxRegister) Check() (uint32, string) {
-	txParam, err := parseRegisterParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -52,7 +52,7 @@ func (t *TxRegister) Check() (uint32, string) {
 }
 
 func (t *TxRegister) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseRegisterParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -0,0 +1,171 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/amolabs/amoabci/amo/code"
+)
+
+// ParseError tells why a tx failed to be parsed, with the tx code to report
+// and the name of the field in question if any.
+type ParseError struct {
+	Code  uint32
+	Field string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	if len(e.Field) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("bad field '%s': %s", e.Field, e.Err.Error())
+}
+
+// TxConstructor makes a tx of a certain type out of its base, parsing the
+// payload into the param of the tx type.
+type TxConstructor func(base TxBase) Tx
+
+// TxRegistry maps tx types to their constructors. Each protocol version has
+// its own registry inheriting from that of the previous protocol version.
+type TxRegistry map[string]TxConstructor
+
+// Extend returns a new registry adding or replacing the tx types given.
+func (r TxRegistry) Extend(more TxRegistry) TxRegistry {
+	registry := TxRegistry{}
+	for txType, constructor := range r {
+		registry[txType] = constructor
+	}
+	for txType, constructor := range more {
+		registry[txType] = constructor
+	}
+	return registry
+}
+
+// Classify makes a tx of the type given in base. Tx of an unknown type is
+// left as it is, which fails in Check().
+func (r TxRegistry) Classify(base TxBase) Tx {
+	constructor, ok := r[base.Type]
+	if !ok {
+		return &base
+	}
+	return constructor(base)
+}
+
+// Parse makes a tx out of its JSON form, ignoring unknown fields.
+func (r TxRegistry) Parse(txBytes []byte) (Tx, error) {
+	var base TxBase
+
+	err := json.Unmarshal(txBytes, &base)
+	if err != nil {
+		return nil, err
+	}
+	// sequence and chain id are not parts of tx before AMOProtocolV7
+	base.Sequence = 0
+	base.ChainID = ""
+
+	return r.Classify(base), nil
+}
+
+// ParseStrict rejects unknown tx types and unknown fields in either tx or its
+// payload, and any field failed to be parsed into the param of the tx type.
+func (r TxRegistry) ParseStrict(txBytes []byte) (Tx, error) {
+	var base TxBase
+
+	err := json.Unmarshal(txBytes, &base)
+	if err != nil {
+		return nil, err
+	}
+	err = decodeStrict(txBytes, &base)
+	if err != nil {
+		return nil, err
+	}
+
+	constructor, ok := r[base.Type]
+	if !ok {
+		return nil, &ParseError{
+			Code: code.TxCodeUnknownTxType,
+			Err:  fmt.Errorf("unknown tx type '%s'", base.Type),
+		}
+	}
+	t := constructor(base)
+
+	param := reflect.ValueOf(t).Elem().FieldByName("Param")
+	if !param.IsValid() {
+		return t, nil
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(base.Payload, &fields)
+	if err != nil {
+		return nil, &ParseError{
+			Code:  code.TxCodeBadParam,
+			Field: "payload",
+			Err:   err,
+		}
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// one field at a time to tell which field is in question
+	for _, key := range keys {
+		b, _ := json.Marshal(map[string]json.RawMessage{key: fields[key]})
+		err = decodeStrict(b, reflect.New(param.Type()).Interface())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+func decodeStrict(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	// NOTE: encoding/json has no error type for unknown fields
+	const unknownField = "json: unknown field "
+	if strings.HasPrefix(err.Error(), unknownField) {
+		field := strings.Trim(err.Error()[len(unknownField):], `"`)
+		return &ParseError{
+			Code:  code.TxCodeUnknownField,
+			Field: field,
+			Err:   fmt.Errorf("unknown field"),
+		}
+	}
+
+	var field string
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(b, &fields) == nil && len(fields) == 1 {
+		for key := range fields {
+			field = key
+		}
+	}
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && len(typeErr.Field) > 0 {
+		field = typeErr.Field
+	}
+	return &ParseError{
+		Code:  code.TxCodeBadField,
+		Field: field,
+		Err:   err,
+	}
+}
+
+// Types returns the tx types in the registry in alphabetical order.
+func (r TxRegistry) Types() []string {
+	txTypes := make([]string, 0, len(r))
+	for txType := range r {
+		txTypes = append(txTypes, txType)
+	}
+	sort.Strings(txTypes)
+	return txTypes
+}
@@ -0,0 +1,44 @@
+package tx
+
+// tx tables of the protocol versions, the same as the ones in package amo
+var (
+	testTxRegistry = TxRegistry{
+		"transfer": NewTxTransfer,
+		"stake":    NewTxStake,
+		"withdraw": NewTxWithdraw,
+		"delegate": NewTxDelegate,
+		"retract":  NewTxRetract,
+		"setup":    NewTxSetup,
+		"close":    NewTxClose,
+		"register": NewTxRegister,
+		"discard":  NewTxDiscard,
+		"request":  NewTxRequest,
+		"cancel":   NewTxCancel,
+		"grant":    NewTxGrant,
+		"revoke":   NewTxRevoke,
+		"claim":    NewTxClaim,
+		"dismiss":  NewTxDismiss,
+		"issue":    NewTxIssue,
+		"propose":  NewTxPropose,
+		"vote":     NewTxVote,
+		"lock":     NewTxLock,
+		"burn":     NewTxBurn,
+	}
+	testTxRegistryV5 = testTxRegistry.Extend(TxRegistry{
+		"transfer": NewTxTransferV5,
+	})
+	testTxRegistryV6 = testTxRegistryV5.Extend(TxRegistry{
+		"did.claim":   NewTxDIDClaim,
+		"did.dismiss": NewTxDIDDismiss,
+		"did.issue":   NewTxDIDIssue,
+		"did.revoke":  NewTxDIDRevoke,
+	})
+	testTxRegistryV7 = testTxRegistryV6.Extend(TxRegistry{
+		"propose":          NewTxProposeV7,
+		"vote":             NewTxVoteV7,
+		"rotate_validator": NewTxRotateValidator,
+		"unlock_early":     NewTxUnlockEarly,
+		"deposit_draft":    NewTxDepositDraft,
+		"withdraw_draft":   NewTxWithdrawDraft,
+	})
+)
@@ -40,7 +40,7 @@ type TxRequest struct {
 var _ Tx = &TxRequest{}
 
 func (t *TxRequest) Check() (uint32, string) {
-	txParam, err := parseRequestParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -54,7 +54,7 @@ func (t *TxRequest) Check() (uint32, string) {
 }
 
 func (t *TxRequest) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseRequestParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -31,7 +31,7 @@ type TxRetract struct {
 var _ Tx = &TxRetract{}
 
 func (t *TxRetract) Check() (uint32, string) {
-	_, err := parseRetractParam(t.getPayload())
+	err := t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -40,7 +40,7 @@ func (t *TxRetract) Check() (uint32, string) {
 }
 
 func (t *TxRetract) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseRetractParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -34,7 +34,7 @@ type TxRevoke struct {
 var _ Tx = &TxRevoke{}
 
 func (t *TxRevoke) Check() (uint32, string) {
-	txParam, err := parseRevokeParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -50,7 +50,7 @@ func (t *TxRevoke) Check() (uint32, string) {
 
 // TODO: fix: use GetUsage
 func (t *TxRevoke) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseRevokeParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
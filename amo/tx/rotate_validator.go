@@ -0,0 +1,80 @@
+package tx
+
+import (
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+)
+
+type RotateValidatorParam struct {
+	Validator tmbytes.HexBytes `json:"validator"`
+}
+
+func parseRotateValidatorParam(raw []byte) (RotateValidatorParam, error) {
+	var param RotateValidatorParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxRotateValidator struct {
+	TxBase
+	Param RotateValidatorParam `json:"-"`
+}
+
+var _ Tx = &TxRotateValidator{}
+
+func (t *TxRotateValidator) Check() (uint32, string) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+
+	if len(txParam.Validator) != ed25519.PubKeyEd25519Size {
+		return code.TxCodeBadValidator, "bad validator key"
+	}
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxRotateValidator) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error(), nil
+	}
+
+	stake := store.GetStake(t.GetSender(), false)
+	if stake == nil {
+		return code.TxCodeNoStake, "no stake", nil
+	}
+
+	// a hibernating validator is expected to come back with the key it left
+	if store.GetHibernate(stake.Validator.Address(), false) != nil {
+		return code.TxCodePermissionDenied, "validator is hibernating", nil
+	}
+
+	var k ed25519.PubKeyEd25519
+	copy(k[:], txParam.Validator)
+
+	err = store.RotateValidator(t.GetSender(), k)
+	if err != nil {
+		switch err {
+		case code.GetError(code.TxCodeNoStake):
+			return code.TxCodeNoStake, err.Error(), nil
+		case code.GetError(code.TxCodeBadValidator):
+			return code.TxCodeBadValidator, "same validator key", nil
+		case code.GetError(code.TxCodePermissionDenied):
+			return code.TxCodePermissionDenied, "validator key in use", nil
+		default:
+			return code.TxCodeUnknown, err.Error(), nil
+		}
+	}
+
+	return code.TxCodeOK, "ok", nil
+}
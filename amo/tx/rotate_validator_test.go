@@ -0,0 +1,112 @@
+package tx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+	"github.com/amolabs/amoabci/crypto/p256"
+)
+
+func makeTestTxV7(txType string, seed string, payload []byte) Tx {
+	privKey := p256.GenPrivKeyFromSecret([]byte(seed))
+	addr := privKey.PubKey().Address()
+	trans := TxBase{
+		Type:    txType,
+		Sender:  addr,
+		Payload: payload,
+	}
+	trans.Sign(privKey)
+	return testTxRegistryV7.Classify(trans)
+}
+
+func TestRotateValidator(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+
+	var oldVal, newVal, bobVal ed25519.PubKeyEd25519
+	copy(oldVal[:], tmrand.Bytes(32))
+	copy(newVal[:], tmrand.Bytes(32))
+	copy(bobVal[:], tmrand.Bytes(32))
+	s.SetUnlockedStake(alice.addr, &types.Stake{
+		Amount:    *new(types.Currency).Set(2000),
+		Validator: oldVal,
+	})
+	s.SetLockedStake(alice.addr, &types.Stake{
+		Amount:    *new(types.Currency).Set(1000),
+		Validator: oldVal,
+	}, 10)
+	s.SetUnlockedStake(bob.addr, &types.Stake{
+		Amount:    *new(types.Currency).Set(2000),
+		Validator: bobVal,
+	})
+
+	// check
+	payload, _ := json.Marshal(RotateValidatorParam{
+		Validator: tmrand.Bytes(31),
+	})
+	tx := makeTestTxV7("rotate_validator", "alice", payload)
+	rc, _ := tx.Check()
+	assert.Equal(t, code.TxCodeBadValidator, rc)
+
+	// no stake
+	payload, _ = json.Marshal(RotateValidatorParam{
+		Validator: newVal[:],
+	})
+	tx = makeTestTxV7("rotate_validator", "eve", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeNoStake, rc)
+
+	// same key
+	payload, _ = json.Marshal(RotateValidatorParam{
+		Validator: oldVal[:],
+	})
+	tx = makeTestTxV7("rotate_validator", "alice", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeBadValidator, rc)
+
+	// key of another validator
+	payload, _ = json.Marshal(RotateValidatorParam{
+		Validator: bobVal[:],
+	})
+	tx = makeTestTxV7("rotate_validator", "alice", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+
+	// hibernating validator
+	s.SetHibernate(oldVal.Address(), &types.Hibernate{Start: 1, End: 5})
+	payload, _ = json.Marshal(RotateValidatorParam{
+		Validator: newVal[:],
+	})
+	tx = makeTestTxV7("rotate_validator", "alice", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+	s.DeleteHibernate(oldVal.Address())
+
+	// ok
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+
+	stake := s.GetUnlockedStake(alice.addr, false)
+	assert.Equal(t, newVal, stake.Validator)
+	assert.Equal(t, *new(types.Currency).Set(2000), stake.Amount)
+	lockedStakes := s.GetLockedStakes(alice.addr, false)
+	assert.Equal(t, 1, len(lockedStakes))
+	assert.Equal(t, newVal, lockedStakes[0].Validator)
+	assert.Equal(t, alice.addr,
+		crypto.Address(s.GetHolderByValidator(newVal.Address(), false)))
+	assert.Nil(t, s.GetHolderByValidator(oldVal.Address(), false))
+	assert.Equal(t, alice.addr,
+		crypto.Address(s.GetHolderByRetiredValidator(oldVal.Address(), false)))
+}
@@ -36,7 +36,7 @@ var _ Tx = &TxSetup{}
 
 func (t *TxSetup) Check() (uint32, string) {
 	// TOOD: check url format
-	_, err := parseSetupParam(t.getPayload())
+	err := t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
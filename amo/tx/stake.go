@@ -35,7 +35,7 @@ type TxStake struct {
 var _ Tx = &TxStake{}
 
 func (t *TxStake) Check() (uint32, string) {
-	txParam, err := parseStakeParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -49,7 +49,7 @@ func (t *TxStake) Check() (uint32, string) {
 }
 
 func (t *TxStake) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseStakeParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
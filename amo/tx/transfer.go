@@ -33,7 +33,7 @@ type TxTransfer struct {
 var _ Tx = &TxTransfer{}
 
 func (t *TxTransfer) Check() (uint32, string) {
-	txParam, err := parseTransferParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -48,7 +48,7 @@ func (t *TxTransfer) Check() (uint32, string) {
 }
 
 func (t *TxTransfer) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseTransferParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -34,7 +34,7 @@ type TxTransferV5 struct {
 var _ Tx = &TxTransferV5{}
 
 func (t *TxTransferV5) Check() (uint32, string) {
-	txParam, err := parseTransferParamV5(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -53,7 +53,7 @@ func (t *TxTransferV5) Check() (uint32, string) {
 }
 
 func (t *TxTransferV5) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseTransferParamV5(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
@@ -69,6 +69,8 @@ type Tx interface {
 	GetSender() crypto.Address
 	GetFee() types.Currency
 	GetLastHeight() int64
+	GetSequence() uint64
+	GetChainID() string
 	getPayload() json.RawMessage
 	getSignature() Signature
 	getSigningBytes() []byte
@@ -76,6 +78,7 @@ type Tx interface {
 	// ops
 	Sign(privKey crypto.PrivKey) error
 	Verify() bool
+	VerifyStrict() bool
 	Check() (uint32, string)
 	Execute(store *store.Store) (uint32, string, []abci.Event)
 }
@@ -86,9 +89,13 @@ type TxBase struct {
 	Type       string          `json:"type"`
 	Sender     crypto.Address  `json:"sender"`
 	Fee        types.Currency  `json:"fee"`
-	LastHeight string          `json:"last_height"` // num as string
-	Payload    json.RawMessage `json:"payload"`     // TODO: change to txparam
+	LastHeight string          `json:"last_height"`        // num as string
+	Sequence   uint64          `json:"sequence,omitempty"` // since v7
+	ChainID    string          `json:"chain_id,omitempty"` // since v7
+	Payload    json.RawMessage `json:"payload"`            // TODO: change to txparam
 	Signature  Signature       `json:"signature"`
+	// error in parsing Payload into the param of the tx type
+	paramErr error
 }
 
 type TxToSign struct {
@@ -96,151 +103,12 @@ type TxToSign struct {
 	Sender     crypto.Address  `json:"sender"`
 	Fee        types.Currency  `json:"fee"`
 	LastHeight string          `json:"last_height"` // num as string
+	Sequence   uint64          `json:"sequence,omitempty"`
+	ChainID    string          `json:"chain_id,omitempty"`
 	Payload    json.RawMessage `json:"payload"`
 	Signature  Signature       `json:"-"`
 }
 
-func classifyTx(base TxBase) Tx {
-	var t Tx
-	// TODO: use err return from parseSomethingParam()
-	switch base.Type {
-	case "transfer":
-		param, _ := parseTransferParam(base.Payload)
-		t = &TxTransfer{
-			TxBase: base,
-			Param:  param,
-		}
-	case "stake":
-		param, _ := parseStakeParam(base.Payload)
-		t = &TxStake{
-			TxBase: base,
-			Param:  param,
-		}
-	case "withdraw":
-		param, _ := parseWithdrawParam(base.Payload)
-		t = &TxWithdraw{
-			TxBase: base,
-			Param:  param,
-		}
-	case "delegate":
-		param, _ := parseDelegateParam(base.Payload)
-		t = &TxDelegate{
-			TxBase: base,
-			Param:  param,
-		}
-	case "retract":
-		param, _ := parseRetractParam(base.Payload)
-		t = &TxRetract{
-			TxBase: base,
-			Param:  param,
-		}
-	case "setup":
-		param, _ := parseSetupParam(base.Payload)
-		t = &TxSetup{
-			TxBase: base,
-			Param:  param,
-		}
-	case "close":
-		param, _ := parseCloseParam(base.Payload)
-		t = &TxClose{
-			TxBase: base,
-			Param:  param,
-		}
-	case "register":
-		param, _ := parseRegisterParam(base.Payload)
-		t = &TxRegister{
-			TxBase: base,
-			Param:  param,
-		}
-	case "discard":
-		param, _ := parseDiscardParam(base.Payload)
-		t = &TxDiscard{
-			TxBase: base,
-			Param:  param,
-		}
-	case "request":
-		param, _ := parseRequestParam(base.Payload)
-		t = &TxRequest{
-			TxBase: base,
-			Param:  param,
-		}
-	case "cancel":
-		param, _ := parseCancelParam(base.Payload)
-		t = &TxCancel{
-			TxBase: base,
-			Param:  param,
-		}
-	case "grant":
-		param, _ := parseGrantParam(base.Payload)
-		t = &TxGrant{
-			TxBase: base,
-			Param:  param,
-		}
-	case "revoke":
-		param, _ := parseRevokeParam(base.Payload)
-		t = &TxRevoke{
-			TxBase: base,
-			Param:  param,
-		}
-	case "claim":
-		param, _ := parseClaimParam(base.Payload)
-		t = &TxClaim{
-			TxBase: base,
-			Param:  param,
-		}
-	case "dismiss":
-		param, _ := parseDismissParam(base.Payload)
-		t = &TxDismiss{
-			TxBase: base,
-			Param:  param,
-		}
-	case "issue":
-		param, _ := parseIssueParam(base.Payload)
-		t = &TxIssue{
-			TxBase: base,
-			Param:  param,
-		}
-	case "propose":
-		param, _ := parseProposeParam(base.Payload)
-		t = &TxPropose{
-			TxBase: base,
-			Param:  param,
-		}
-	case "vote":
-		param, _ := parseVoteParam(base.Payload)
-		t = &TxVote{
-			TxBase: base,
-			Param:  param,
-		}
-	case "lock":
-		param, _ := parseLockParam(base.Payload)
-		t = &TxLock{
-			TxBase: base,
-			Param:  param,
-		}
-	case "burn":
-		param, _ := parseBurnParam(base.Payload)
-		t = &TxBurn{
-			TxBase: base,
-			Param:  param,
-		}
-	default:
-		t = &base
-	}
-	return t
-}
-
-func ParseTx(txBytes []byte) (Tx, error) {
-	var base TxBase
-
-	err := json.Unmarshal(txBytes, &base)
-	if err != nil {
-		return nil, err
-	}
-
-	return classifyTx(base), nil
-}
-
 // accessors
 
 func (t *TxBase) GetType() string {
@@ -265,6 +133,14 @@ func (t *TxBase) GetLastHeight() int64 {
 	return lastHeight
 }
 
+func (t *TxBase) GetSequence() uint64 {
+	return t.Sequence
+}
+
+func (t *TxBase) GetChainID() string {
+	return t.ChainID
+}
+
 func (t *TxBase) getPayload() json.RawMessage {
 	return t.Payload
 }
@@ -274,7 +150,15 @@ func (t *TxBase) getSignature() Signature {
 }
 
 func (t *TxBase) getSigningBytes() []byte {
-	var tts TxToSign = TxToSign(*t)
+	tts := TxToSign{
+		Type:       t.Type,
+		Sender:     t.Sender,
+		Fee:        t.Fee,
+		LastHeight: t.LastHeight,
+		Sequence:   t.Sequence,
+		ChainID:    t.ChainID,
+		Payload:    t.Payload,
+	}
 	b, _ := json.Marshal(tts)
 	/* XXX: nothing to do here
 	if err != nil {
@@ -316,6 +200,12 @@ func (t *TxBase) Verify() bool {
 	return t.Signature.PubKey.VerifyBytes(sb, t.Signature.SigBytes)
 }
 
+// VerifyStrict verifies the signature as Verify does, while accepting it only
+// in low-S form. Used since protocol v7.
+func (t *TxBase) VerifyStrict() bool {
+	return p256.IsLowS(t.Signature.SigBytes) && t.Verify()
+}
+
 func (t *TxBase) Check() (uint32, string) {
 	rc := code.TxCodeUnknown
 	info := "unknown transaction type"
@@ -60,7 +60,7 @@ func makeTestTx(txType string, seed string, payload []byte) Tx {
 		Payload: payload,
 	}
 	trans.Sign(privKey)
-	return classifyTx(trans)
+	return testTxRegistry.Classify(trans)
 }
 
 func makeTestPubKey(seed string) p256.PubKeyP256 {
@@ -154,7 +154,7 @@ func TestParseTx(t *testing.T) {
 			Amount: *bal,
 		},
 	}
-	parsedTx, err := ParseTx(bytes)
+	parsedTx, err := testTxRegistry.Parse(bytes)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, parsedTx)
 }
@@ -0,0 +1,113 @@
+package tx
+
+import (
+	"encoding/json"
+	"math/big"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type UnlockEarlyParam struct {
+	// as shown in `unlock_height` of /stake/locked query
+	UnlockHeight int64 `json:"unlock_height"`
+}
+
+func parseUnlockEarlyParam(raw []byte) (UnlockEarlyParam, error) {
+	var param UnlockEarlyParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+type TxUnlockEarly struct {
+	TxBase
+	Param UnlockEarlyParam `json:"-"`
+}
+
+var _ Tx = &TxUnlockEarly{}
+
+func (t *TxUnlockEarly) Check() (uint32, string) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+
+	if txParam.UnlockHeight <= 0 {
+		return code.TxCodeBadParam, "improper unlock height"
+	}
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxUnlockEarly) Execute(s *store.Store) (uint32, string, []abci.Event) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error(), nil
+	}
+
+	// Locked stakes are loosened at the end of every block, so the tranche
+	// to be unlocked at UnlockHeight is stored with the following height
+	// until the end of the current block.
+	height := txParam.UnlockHeight - StateBlockHeight + 1
+	if height <= 0 {
+		return code.TxCodeBadParam, "improper unlock height", nil
+	}
+
+	locked := s.GetLockedStake(t.GetSender(), height, false)
+	if locked == nil {
+		return code.TxCodeNoStake, "no locked stake", nil
+	}
+
+	// penalty = amount * rate
+	af := new(big.Float).SetInt(&locked.Amount.Int)
+	rf := new(big.Float).SetFloat64(ConfigAMOApp.EarlyUnlockPenaltyRate)
+	af.Mul(af, rf)
+	penalty := new(types.Currency)
+	af.Int(&penalty.Int)
+
+	err = s.UnlockStakeEarly(t.GetSender(), height, *penalty)
+	if err != nil {
+		switch err {
+		case code.GetError(code.TxCodeNoStake):
+			return code.TxCodeNoStake, err.Error(), nil
+		case code.GetError(code.TxCodeImproperStakeAmount):
+			return code.TxCodeImproperStakeAmount, err.Error(), nil
+		default:
+			return code.TxCodeUnknown, err.Error(), nil
+		}
+	}
+
+	// penalty is burned unless it goes to the community pool
+	penaltyTo := "burn"
+	if ConfigAMOApp.EarlyUnlockPenaltyToPool {
+		pool := s.GetCommunityPool(false)
+		pool.Add(penalty)
+		s.SetCommunityPool(pool)
+		penaltyTo = "pool"
+	}
+
+	released := new(types.Currency).Set(0)
+	released.Add(&locked.Amount).Sub(penalty)
+	addressJson, _ := json.Marshal(t.GetSender())
+	amountJson, _ := json.Marshal(released)
+	penaltyJson, _ := json.Marshal(penalty)
+	penaltyToJson, _ := json.Marshal(penaltyTo)
+	events := []abci.Event{}
+	events = append(events, abci.Event{
+		Type: "stake_unlock_early",
+		Attributes: []kv.Pair{
+			{Key: []byte("address"), Value: addressJson},
+			{Key: []byte("amount"), Value: amountJson},
+			{Key: []byte("penalty"), Value: penaltyJson},
+			{Key: []byte("penalty_to"), Value: penaltyToJson},
+		},
+	})
+
+	return code.TxCodeOK, "ok", events
+}
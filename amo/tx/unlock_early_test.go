@@ -0,0 +1,94 @@
+package tx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func TestUnlockEarly(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	ConfigAMOApp.EarlyUnlockPenaltyRate = 0.1
+	ConfigAMOApp.EarlyUnlockPenaltyToPool = false
+	StateBlockHeight = 11
+
+	var k ed25519.PubKeyEd25519
+	copy(k[:], tmrand.Bytes(32))
+	s.SetUnlockedStake(alice.addr, &types.Stake{
+		Amount:    *new(types.Currency).Set(500),
+		Validator: k,
+	})
+	s.SetLockedStake(alice.addr, &types.Stake{
+		Amount:    *new(types.Currency).Set(1000),
+		Validator: k,
+	}, 10)
+	s.SetLockedStake(alice.addr, &types.Stake{
+		Amount:    *new(types.Currency).Set(2000),
+		Validator: k,
+	}, 20)
+
+	// check
+	payload, _ := json.Marshal(UnlockEarlyParam{UnlockHeight: 0})
+	tx := makeTestTxV7("unlock_early", "alice", payload)
+	rc, _ := tx.Check()
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	// already unlocked
+	payload, _ = json.Marshal(UnlockEarlyParam{UnlockHeight: 10})
+	tx = makeTestTxV7("unlock_early", "alice", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	// no such tranche
+	payload, _ = json.Marshal(UnlockEarlyParam{UnlockHeight: 21})
+	tx = makeTestTxV7("unlock_early", "alice", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeNoStake, rc)
+	tx = makeTestTxV7("unlock_early", "bob", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeNoStake, rc)
+
+	// burn penalty
+	payload, _ = json.Marshal(UnlockEarlyParam{UnlockHeight: 20})
+	tx = makeTestTxV7("unlock_early", "alice", payload)
+	rc, _, events := tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "stake_unlock_early", events[0].Type)
+	assert.Equal(t, []byte(`"100"`), events[0].Attributes[2].Value)
+	assert.Equal(t, []byte(`"burn"`), events[0].Attributes[3].Value)
+
+	assert.Nil(t, s.GetLockedStake(alice.addr, 10, false))
+	unlocked := s.GetUnlockedStake(alice.addr, false)
+	assert.Equal(t, *new(types.Currency).Set(1400), unlocked.Amount)
+	stake := s.GetStake(alice.addr, false)
+	assert.Equal(t, *new(types.Currency).Set(3400), stake.Amount)
+	assert.Equal(t, new(types.Currency).Set(0), s.GetCommunityPool(false))
+
+	// penalty to pool
+	ConfigAMOApp.EarlyUnlockPenaltyToPool = true
+	payload, _ = json.Marshal(UnlockEarlyParam{UnlockHeight: 30})
+	tx = makeTestTxV7("unlock_early", "alice", payload)
+	rc, _, events = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, []byte(`"pool"`), events[0].Attributes[3].Value)
+
+	unlocked = s.GetUnlockedStake(alice.addr, false)
+	assert.Equal(t, *new(types.Currency).Set(3200), unlocked.Amount)
+	assert.Equal(t, 0, len(s.GetLockedStakes(alice.addr, false)))
+	assert.Equal(t, new(types.Currency).Set(200), s.GetCommunityPool(false))
+
+	ConfigAMOApp.EarlyUnlockPenaltyToPool = false
+}
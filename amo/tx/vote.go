@@ -14,6 +14,8 @@ import (
 type VoteParam struct {
 	DraftID uint32 `json:"draft_id"`
 	Approve bool   `json:"approve"`
+	// since AMOProtocolV7
+	Option string `json:"option,omitempty"`
 }
 
 func parseVoteParam(raw []byte) (VoteParam, error) {
@@ -33,7 +35,7 @@ type TxVote struct {
 var _ Tx = &TxVote{}
 
 func (t *TxVote) Check() (uint32, string) {
-	_, err := parseVoteParam(t.getPayload())
+	err := t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -41,12 +43,13 @@ func (t *TxVote) Check() (uint32, string) {
 }
 
 func (t *TxVote) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseVoteParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
 
-	stakes := store.GetTopStakes(ConfigAMOApp.MaxValidators, t.GetSender(), false)
+	stakes := store.GetTopStakes(ConfigAMOApp.MaxValidators,
+		ConfigAMOApp.SelfStakeRule(), t.GetSender(), false)
 	if len(stakes) == 0 {
 		return code.TxCodePermissionDenied, "no permission to vote", nil
 	}
@@ -0,0 +1,102 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+// TxVoteV7 takes one of yes, no, abstain and no_with_veto as a vote option,
+// and allows a voter to change the vote until the vote gets closed. Delegators
+// of top stake holders may vote as well, for their own share.
+type TxVoteV7 struct {
+	TxBase
+	Param VoteParam `json:"-"`
+}
+
+var _ Tx = &TxVoteV7{}
+
+func (t *TxVoteV7) Check() (uint32, string) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	if len(txParam.Option) > 0 && !types.IsValidVoteOption(txParam.Option) {
+		return code.TxCodeBadParam, "unknown vote option"
+	}
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxVoteV7) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error(), nil
+	}
+
+	option := txParam.Option
+	if len(option) == 0 {
+		option = (&types.Vote{Approve: txParam.Approve}).GetOption()
+	}
+	if !types.IsValidVoteOption(option) {
+		return code.TxCodeBadParam, "unknown vote option", nil
+	}
+
+	// either a top stake holder or its delegator
+	stakes := store.GetTopStakes(ConfigAMOApp.MaxValidators,
+		ConfigAMOApp.SelfStakeRule(), t.GetSender(), false)
+	if len(stakes) == 0 {
+		delegate := store.GetDelegate(t.GetSender(), false)
+		if delegate == nil {
+			return code.TxCodePermissionDenied, "no permission to vote", nil
+		}
+		stakes = store.GetTopStakes(ConfigAMOApp.MaxValidators,
+			ConfigAMOApp.SelfStakeRule(), delegate.Delegatee, false)
+		if len(stakes) == 0 {
+			return code.TxCodePermissionDenied, "no permission to vote", nil
+		}
+	}
+
+	draft := store.GetDraft(txParam.DraftID, false)
+	if draft == nil {
+		return code.TxCodeNonExistingDraft, "non-existing draft", nil
+	}
+
+	if bytes.Equal(draft.Proposer, t.GetSender()) {
+		return code.TxCodeSelfTransaction, "proposer cannot vote on own draft", nil
+	}
+
+	if !(draft.OpenCount == 0 &&
+		draft.CloseCount > 0 &&
+		draft.ApplyCount > 0) {
+		return code.TxCodeVoteNotOpen, "vote is not opened", nil
+	}
+
+	vote := &types.Vote{
+		Approve: option == types.VoteOptionYes,
+		Option:  option,
+	}
+	store.SetVote(txParam.DraftID, t.GetSender(), vote)
+
+	// event
+	idJson, _ := json.Marshal(txParam.DraftID)
+	addressJson, _ := json.Marshal(t.GetSender())
+	optionJson, _ := json.Marshal(option)
+	events := []abci.Event{
+		{
+			Type: "vote",
+			Attributes: []kv.Pair{
+				{Key: []byte("id"), Value: idJson},
+				{Key: []byte("address"), Value: addressJson},
+				{Key: []byte("option"), Value: optionJson},
+			},
+		},
+	}
+
+	return code.TxCodeOK, "ok", events
+}
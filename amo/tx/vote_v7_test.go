@@ -0,0 +1,91 @@
+package tx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func TestVoteV7(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	ConfigAMOApp.MaxValidators = uint64(100)
+
+	var k ed25519.PubKeyEd25519
+	copy(k[:], tmrand.Bytes(32))
+	assert.NoError(t, s.SetUnlockedStake(makeAccAddr("voter1"), &types.Stake{
+		Validator: k,
+		Amount:    *new(types.Currency).Set(10000000),
+	}))
+	draftID := uint32(1)
+	s.SetDraft(draftID, &types.Draft{
+		Proposer:   makeAccAddr("proposer"),
+		Kind:       types.DraftKindText,
+		OpenCount:  int64(0),
+		CloseCount: int64(1000),
+		ApplyCount: int64(10000),
+	})
+
+	vote := func(param VoteParam) uint32 {
+		payload, _ := json.Marshal(param)
+		t1 := makeTestTxV7("vote", "voter1", payload)
+		rc, _ := t1.Check()
+		if rc != code.TxCodeOK {
+			return rc
+		}
+		rc, _, _ = t1.Execute(s)
+		return rc
+	}
+
+	// unknown option
+	rc := vote(VoteParam{DraftID: draftID, Option: "maybe"})
+	assert.Equal(t, code.TxCodeBadParam, rc)
+
+	// legacy param
+	rc = vote(VoteParam{DraftID: draftID, Approve: false})
+	assert.Equal(t, code.TxCodeOK, rc)
+	v := s.GetVote(draftID, makeAccAddr("voter1"), false)
+	assert.Equal(t, types.VoteOptionNo, v.GetOption())
+
+	// change vote
+	rc = vote(VoteParam{DraftID: draftID, Option: types.VoteOptionAbstain})
+	assert.Equal(t, code.TxCodeOK, rc)
+	v = s.GetVote(draftID, makeAccAddr("voter1"), false)
+	assert.Equal(t, types.VoteOptionAbstain, v.GetOption())
+	assert.False(t, v.Approve)
+
+	rc = vote(VoteParam{DraftID: draftID, Option: types.VoteOptionYes})
+	assert.Equal(t, code.TxCodeOK, rc)
+	v = s.GetVote(draftID, makeAccAddr("voter1"), false)
+	assert.True(t, v.Approve)
+	assert.Equal(t, 1, len(s.GetVotes(draftID, false)))
+
+	// no more change after the vote gets closed
+	draft := s.GetDraft(draftID, false)
+	draft.CloseCount = 0
+	s.SetDraft(draftID, draft)
+	rc = vote(VoteParam{DraftID: draftID, Option: types.VoteOptionVeto})
+	assert.Equal(t, code.TxCodeVoteNotOpen, rc)
+
+	// delegator votes for its own share
+	draft.CloseCount = 1000
+	s.SetDraft(draftID, draft)
+	s.SetDelegate(makeAccAddr("delegator"), &types.Delegate{
+		Delegatee: makeAccAddr("voter1"),
+		Amount:    *new(types.Currency).Set(100),
+	})
+	payload, _ := json.Marshal(VoteParam{DraftID: draftID, Option: types.VoteOptionNo})
+	rc, _, _ = makeTestTxV7("vote", "delegator", payload).Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = makeTestTxV7("vote", "nobody", payload).Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+}
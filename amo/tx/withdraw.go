@@ -31,7 +31,7 @@ type TxWithdraw struct {
 func (t *TxWithdraw) Check() (uint32, string) {
 	// TODO: check format
 	//txParam, err := parseWithdrawParam(t.Payload)
-	_, err := parseWithdrawParam(t.getPayload())
+	err := t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error()
 	}
@@ -40,7 +40,7 @@ func (t *TxWithdraw) Check() (uint32, string) {
 }
 
 func (t *TxWithdraw) Execute(store *store.Store) (uint32, string, []abci.Event) {
-	txParam, err := parseWithdrawParam(t.getPayload())
+	txParam, err := t.Param, t.paramErr
 	if err != nil {
 		return code.TxCodeBadParam, err.Error(), nil
 	}
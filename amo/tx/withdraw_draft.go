@@ -0,0 +1,96 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/kv"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+type WithdrawDraftParam struct {
+	DraftID uint32 `json:"draft_id"`
+}
+
+func parseWithdrawDraftParam(raw []byte) (WithdrawDraftParam, error) {
+	var param WithdrawDraftParam
+	err := json.Unmarshal(raw, &param)
+	if err != nil {
+		return param, err
+	}
+	return param, nil
+}
+
+// TxWithdrawDraft cancels a draft before its vote gets closed. Co-sponsors get
+// their deposits back in full, while the proposer loses
+// DraftWithdrawPenaltyRate of its own share.
+type TxWithdrawDraft struct {
+	TxBase
+	Param WithdrawDraftParam `json:"-"`
+}
+
+var _ Tx = &TxWithdrawDraft{}
+
+func (t *TxWithdrawDraft) Check() (uint32, string) {
+	err := t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error()
+	}
+	return code.TxCodeOK, "ok"
+}
+
+func (t *TxWithdrawDraft) Execute(store *store.Store) (uint32, string, []abci.Event) {
+	txParam, err := t.Param, t.paramErr
+	if err != nil {
+		return code.TxCodeBadParam, err.Error(), nil
+	}
+
+	draft := store.GetDraft(txParam.DraftID, false)
+	if draft == nil {
+		return code.TxCodeNonExistingDraft, "non-existing draft", nil
+	}
+
+	if !bytes.Equal(draft.Proposer, t.GetSender()) {
+		return code.TxCodePermissionDenied, "not the proposer of the draft", nil
+	}
+
+	if !(draft.CloseCount > 0 && draft.ApplyCount > 0) {
+		return code.TxCodeVoteNotOpen, "vote is already closed", nil
+	}
+
+	// penalty = (draft.Deposit - co-sponsors' deposits) * penaltyRate
+	share := new(types.Currency).Set(0).Add(&draft.Deposit)
+	for _, deposit := range store.GetDraftDeposits(txParam.DraftID, false) {
+		share.Sub(&deposit.Amount)
+	}
+	penalty := new(types.Currency)
+	sf := new(big.Float).SetInt(&share.Int)
+	rf := new(big.Float).SetFloat64(ConfigAMOApp.DraftWithdrawPenaltyRate)
+	sf.Mul(sf, rf).Int(&penalty.Int)
+
+	draft.OpenCount = int64(0)
+	draft.CloseCount = int64(0)
+	draft.ApplyCount = int64(0)
+	store.SetDraft(txParam.DraftID, draft)
+
+	idJson, _ := json.Marshal(txParam.DraftID)
+	penaltyJson, _ := json.Marshal(penalty)
+	events := []abci.Event{
+		{
+			Type: "draft_withdraw",
+			Attributes: []kv.Pair{
+				{Key: []byte("id"), Value: idJson},
+				{Key: []byte("penalty"), Value: penaltyJson},
+			},
+		},
+	}
+	events = append(events,
+		store.RefundDraftDeposit(txParam.DraftID, draft, penalty, false)...)
+
+	return code.TxCodeOK, "ok", events
+}
@@ -0,0 +1,55 @@
+package tx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo/code"
+	"github.com/amolabs/amoabci/amo/store"
+	"github.com/amolabs/amoabci/amo/types"
+)
+
+func TestWithdrawDraft(t *testing.T) {
+	// env
+	s, err := store.NewStore(nil, 1, tmdb.NewMemDB(), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	ConfigAMOApp.DraftWithdrawPenaltyRate = 0.5
+
+	s.SetDraft(1, &types.Draft{
+		Proposer:   makeAccAddr("proposer"),
+		Kind:       types.DraftKindText,
+		OpenCount:  int64(0),
+		CloseCount: int64(100),
+		ApplyCount: int64(100),
+		Deposit:    *new(types.Currency).Set(1000),
+	})
+	s.AddDraftDeposit(1, makeAccAddr("sponsor"), new(types.Currency).Set(400))
+
+	payload, _ := json.Marshal(WithdrawDraftParam{DraftID: 2})
+	rc, _, _ := makeTestTxV7("withdraw_draft", "proposer", payload).Execute(s)
+	assert.Equal(t, code.TxCodeNonExistingDraft, rc)
+
+	payload, _ = json.Marshal(WithdrawDraftParam{DraftID: 1})
+	tx := makeTestTxV7("withdraw_draft", "sponsor", payload)
+	rc, _ = tx.Check()
+	assert.Equal(t, code.TxCodeOK, rc)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodePermissionDenied, rc)
+
+	tx = makeTestTxV7("withdraw_draft", "proposer", payload)
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeOK, rc)
+	assert.Equal(t, 0, len(s.GetActiveDraftIDs(false)))
+	// proposer's share 600, half of which is burned
+	assert.Equal(t, new(types.Currency).Set(300),
+		s.GetBalance(makeAccAddr("proposer"), false))
+	assert.Equal(t, new(types.Currency).Set(400),
+		s.GetBalance(makeAccAddr("sponsor"), false))
+
+	// already withdrawn
+	rc, _, _ = tx.Execute(s)
+	assert.Equal(t, code.TxCodeVoteNotOpen, rc)
+}
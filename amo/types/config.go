@@ -1,18 +1,24 @@
 package types
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
 )
 
 const (
 	// hard-coded configs
-	DefaultMaxValidators   = uint64(100)
-	DefaultWeightValidator = float64(2)
-	DefaultWeightDelegator = float64(1)
+	DefaultMaxValidators      = uint64(100)
+	DefaultMaxVotingPowerRate = float64(0) // no cap
+	DefaultWeightValidator    = float64(2)
+	DefaultWeightDelegator    = float64(1)
 
 	DefaultMinStakingUnit = "1000000000000000000000000"
 
+	DefaultMinSelfStakeRate = float64(0)
+	DefaultMinSelfStake     = "0"
+
 	DefaultBlkReward = "0"
 	DefaultTxReward  = "10000000000000000000"
 
@@ -20,6 +26,10 @@ const (
 	DefaultPenaltyRatioM = float64(0.3)
 	DefaultPenaltyRatioL = float64(0.3)
 
+	DefaultMaxEvidenceAge      = int64(0) // no limit
+	DefaultPenaltyReporterRate = float64(0)
+	DefaultPenaltyToPool       = false
+
 	DefaultLazinessWindow     = int64(10000)
 	DefaultLazinessThreshold  = int64(8000)
 	DefaultHibernateThreshold = int64(100)
@@ -27,6 +37,9 @@ const (
 	DefaultBlockBindingWindow = int64(10000)
 	DefaultLockupPeriod       = int64(1000000)
 
+	DefaultEarlyUnlockPenaltyRate   = float64(0.1)
+	DefaultEarlyUnlockPenaltyToPool = false
+
 	DefaultDraftOpenCount  = int64(10000)
 	DefaultDraftCloseCount = int64(10000)
 	DefaultDraftApplyCount = int64(10000)
@@ -34,58 +47,95 @@ const (
 	DefaultDraftQuorumRate = float64(0.3)
 	DefaultDraftPassRate   = float64(0.51)
 	DefaultDraftRefundRate = float64(0.2)
+	DefaultDraftVetoRate   = float64(0.334)
+	DefaultDraftMaxActive  = uint64(5)
+
+	DefaultDraftInitialDepositRate  = float64(1)
+	DefaultDraftWithdrawPenaltyRate = float64(0.5)
+
+	DefaultMaxBlockCost = uint64(0) // no limit
 
 	DefaultUpgradeProtocolHeight  = int64(1)
 	DefaultUpgradeProtocolVersion = uint64(0)
 )
 
 type AMOAppConfig struct {
-	MaxValidators          uint64   `json:"max_validators"`
-	WeightValidator        float64  `json:"weight_validator"`
-	WeightDelegator        float64  `json:"weight_delegator"`
-	MinStakingUnit         Currency `json:"min_staking_unit"`
-	BlkReward              Currency `json:"blk_reward"`
-	TxReward               Currency `json:"tx_reward"`
-	PenaltyRatioM          float64  `json:"penalty_ratio_m"` // malicious validator
-	PenaltyRatioL          float64  `json:"penalty_ratio_l"` // lazy validators
-	LazinessWindow         int64    `json:"laziness_window"`
-	LazinessThreshold      int64    `json:"laziness_threshold"`
-	HibernateThreshold     int64    `json:"hibernate_threshold"`
-	HibernatePeriod        int64    `json:"hibernate_period"`
-	BlockBindingWindow     int64    `json:"block_binding_window"`
-	LockupPeriod           int64    `json:"lockup_period"`
-	DraftOpenCount         int64    `json:"draft_open_count"`
-	DraftCloseCount        int64    `json:"draft_close_count"`
-	DraftApplyCount        int64    `json:"draft_apply_count"`
-	DraftDeposit           Currency `json:"draft_deposit"`
-	DraftQuorumRate        float64  `json:"draft_quorum_rate"`
-	DraftPassRate          float64  `json:"draft_pass_rate"`
-	DraftRefundRate        float64  `json:"draft_refund_rate"`
-	UpgradeProtocolHeight  int64    `json:"upgrade_protocol_height"`
-	UpgradeProtocolVersion uint64   `json:"upgrade_protocol_version"`
+	MaxValidators            uint64   `json:"max_validators"`
+	MaxVotingPowerRate       float64  `json:"max_voting_power_rate"`
+	WeightValidator          float64  `json:"weight_validator"`
+	WeightDelegator          float64  `json:"weight_delegator"`
+	MinStakingUnit           Currency `json:"min_staking_unit"`
+	MinSelfStakeRate         float64  `json:"min_self_stake_rate"`
+	MinSelfStake             Currency `json:"min_self_stake"`
+	BlkReward                Currency `json:"blk_reward"`
+	TxReward                 Currency `json:"tx_reward"`
+	PenaltyRatioM            float64  `json:"penalty_ratio_m"` // malicious validator
+	PenaltyRatioL            float64  `json:"penalty_ratio_l"` // lazy validators
+	MaxEvidenceAge           int64    `json:"max_evidence_age"`
+	PenaltyReporterRate      float64  `json:"penalty_reporter_rate"`
+	PenaltyToPool            bool     `json:"penalty_to_pool"`
+	LazinessWindow           int64    `json:"laziness_window"`
+	LazinessThreshold        int64    `json:"laziness_threshold"`
+	HibernateThreshold       int64    `json:"hibernate_threshold"`
+	HibernatePeriod          int64    `json:"hibernate_period"`
+	BlockBindingWindow       int64    `json:"block_binding_window"`
+	LockupPeriod             int64    `json:"lockup_period"`
+	EarlyUnlockPenaltyRate   float64  `json:"early_unlock_penalty_rate"`
+	EarlyUnlockPenaltyToPool bool     `json:"early_unlock_penalty_to_pool"`
+	DraftOpenCount           int64    `json:"draft_open_count"`
+	DraftCloseCount          int64    `json:"draft_close_count"`
+	DraftApplyCount          int64    `json:"draft_apply_count"`
+	DraftDeposit             Currency `json:"draft_deposit"`
+	DraftQuorumRate          float64  `json:"draft_quorum_rate"`
+	DraftPassRate            float64  `json:"draft_pass_rate"`
+	DraftRefundRate          float64  `json:"draft_refund_rate"`
+	DraftVetoRate            float64  `json:"draft_veto_rate"`
+	DraftInitialDepositRate  float64  `json:"draft_initial_deposit_rate"`
+	DraftWithdrawPenaltyRate float64  `json:"draft_withdraw_penalty_rate"`
+	DraftMaxActive           uint64   `json:"draft_max_active"` // 0 for no limit
+	UpgradeProtocolHeight    int64    `json:"upgrade_protocol_height"`
+	UpgradeProtocolVersion   uint64   `json:"upgrade_protocol_version"`
+	// penalty ratios by evidence type, PenaltyRatioM for the types not listed
+	PenaltyRatios map[string]float64 `json:"penalty_ratios,omitempty"`
+	// minimum fees by tx type, no minimum for the types not listed
+	MinFees map[string]FeeRate `json:"min_fees,omitempty"`
+	// limit on the sum of execution costs of txs in a block, 0 for no limit
+	MaxBlockCost uint64 `json:"max_block_cost,omitempty"`
 }
 
 func NewDefaultAMOAppConfig() (AMOAppConfig, error) {
 	cfg := AMOAppConfig{
-		MaxValidators:          DefaultMaxValidators,
-		WeightValidator:        DefaultWeightValidator,
-		WeightDelegator:        DefaultWeightDelegator,
-		PenaltyRatioM:          DefaultPenaltyRatioM,
-		PenaltyRatioL:          DefaultPenaltyRatioL,
-		LazinessWindow:         DefaultLazinessWindow,
-		LazinessThreshold:      DefaultLazinessThreshold,
-		HibernateThreshold:     DefaultHibernateThreshold,
-		HibernatePeriod:        DefaultHibernatePeriod,
-		BlockBindingWindow:     DefaultBlockBindingWindow,
-		LockupPeriod:           DefaultLockupPeriod,
-		DraftOpenCount:         DefaultDraftOpenCount,
-		DraftCloseCount:        DefaultDraftCloseCount,
-		DraftApplyCount:        DefaultDraftApplyCount,
-		DraftQuorumRate:        DefaultDraftQuorumRate,
-		DraftPassRate:          DefaultDraftPassRate,
-		DraftRefundRate:        DefaultDraftRefundRate,
-		UpgradeProtocolHeight:  DefaultUpgradeProtocolHeight,
-		UpgradeProtocolVersion: DefaultUpgradeProtocolVersion,
+		MaxValidators:            DefaultMaxValidators,
+		MaxVotingPowerRate:       DefaultMaxVotingPowerRate,
+		WeightValidator:          DefaultWeightValidator,
+		WeightDelegator:          DefaultWeightDelegator,
+		MinSelfStakeRate:         DefaultMinSelfStakeRate,
+		PenaltyRatioM:            DefaultPenaltyRatioM,
+		PenaltyRatioL:            DefaultPenaltyRatioL,
+		MaxEvidenceAge:           DefaultMaxEvidenceAge,
+		PenaltyReporterRate:      DefaultPenaltyReporterRate,
+		PenaltyToPool:            DefaultPenaltyToPool,
+		LazinessWindow:           DefaultLazinessWindow,
+		LazinessThreshold:        DefaultLazinessThreshold,
+		HibernateThreshold:       DefaultHibernateThreshold,
+		HibernatePeriod:          DefaultHibernatePeriod,
+		BlockBindingWindow:       DefaultBlockBindingWindow,
+		LockupPeriod:             DefaultLockupPeriod,
+		EarlyUnlockPenaltyRate:   DefaultEarlyUnlockPenaltyRate,
+		EarlyUnlockPenaltyToPool: DefaultEarlyUnlockPenaltyToPool,
+		DraftOpenCount:           DefaultDraftOpenCount,
+		DraftCloseCount:          DefaultDraftCloseCount,
+		DraftApplyCount:          DefaultDraftApplyCount,
+		DraftQuorumRate:          DefaultDraftQuorumRate,
+		DraftPassRate:            DefaultDraftPassRate,
+		DraftRefundRate:          DefaultDraftRefundRate,
+		DraftVetoRate:            DefaultDraftVetoRate,
+		DraftInitialDepositRate:  DefaultDraftInitialDepositRate,
+		DraftWithdrawPenaltyRate: DefaultDraftWithdrawPenaltyRate,
+		DraftMaxActive:           DefaultDraftMaxActive,
+		UpgradeProtocolHeight:    DefaultUpgradeProtocolHeight,
+		UpgradeProtocolVersion:   DefaultUpgradeProtocolVersion,
+		MaxBlockCost:             DefaultMaxBlockCost,
 	}
 
 	tmp, err := new(Currency).SetString(DefaultMinStakingUnit, 10)
@@ -94,6 +144,12 @@ func NewDefaultAMOAppConfig() (AMOAppConfig, error) {
 	}
 	cfg.MinStakingUnit = *tmp
 
+	tmp, err = new(Currency).SetString(DefaultMinSelfStake, 10)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.MinSelfStake = *tmp
+
 	tmp, err = new(Currency).SetString(DefaultBlkReward, 10)
 	if err != nil {
 		return cfg, err
@@ -115,6 +171,11 @@ func NewDefaultAMOAppConfig() (AMOAppConfig, error) {
 	return cfg, nil
 }
 
+// SelfStakeRule returns the minimum self-stake required for validators.
+func (c *AMOAppConfig) SelfStakeRule() SelfStakeRule {
+	return SelfStakeRule{Rate: c.MinSelfStakeRate, Amount: c.MinSelfStake}
+}
+
 type AMOAppConfigGenesis struct {
 	MaxValidators          uint64   `json:"max_validators"`
 	WeightValidator        float64  `json:"weight_validator"`
@@ -181,12 +242,7 @@ func (cfg *AMOAppConfig) Check(
 		return *cfg, nil
 	}
 
-	cfgMap, err := cfg.getMap()
-	if err != nil {
-		return AMOAppConfig{}, err
-	}
-
-	err = json.Unmarshal(txCfgRaw, &txCfgMap)
+	err := json.Unmarshal(txCfgRaw, &txCfgMap)
 	if err != nil {
 		return AMOAppConfig{}, err
 	}
@@ -222,7 +278,7 @@ func (cfg *AMOAppConfig) Check(
 		return AMOAppConfig{}, fmt.Errorf("upgrade protocol config is included")
 	}
 	for key := range txCfgMap {
-		if _, exist := cfgMap[key]; !exist {
+		if !legacyConfigKeys[key] {
 			return AMOAppConfig{}, fmt.Errorf("%s doesn't exist in config map", key)
 		}
 	}
@@ -260,6 +316,365 @@ func (cfg *AMOAppConfig) Check(
 	return AMOAppConfig{}, fmt.Errorf("couldn't finish checking config values successfully")
 }
 
+// legacyConfigKeys are the config fields which can be changed by a draft
+// before protocol v7. Fields added later are changed only by a partial config
+// checked in CheckConfigDiff().
+var legacyConfigKeys = map[string]bool{
+	"max_validators":           true,
+	"weight_validator":         true,
+	"weight_delegator":         true,
+	"min_staking_unit":         true,
+	"blk_reward":               true,
+	"tx_reward":                true,
+	"penalty_ratio_m":          true,
+	"penalty_ratio_l":          true,
+	"laziness_window":          true,
+	"laziness_threshold":       true,
+	"hibernate_threshold":      true,
+	"hibernate_period":         true,
+	"block_binding_window":     true,
+	"lockup_period":            true,
+	"draft_open_count":         true,
+	"draft_close_count":        true,
+	"draft_apply_count":        true,
+	"draft_deposit":            true,
+	"draft_quorum_rate":        true,
+	"draft_pass_rate":          true,
+	"draft_refund_rate":        true,
+	"upgrade_protocol_height":  true,
+	"upgrade_protocol_version": true,
+}
+
+// legacyAMOAppConfig is the layout of AMOAppConfig before protocol v7, in
+// which config gets stored below v7 to keep the app hash of the chains running.
+type legacyAMOAppConfig struct {
+	MaxValidators          uint64   `json:"max_validators"`
+	WeightValidator        float64  `json:"weight_validator"`
+	WeightDelegator        float64  `json:"weight_delegator"`
+	MinStakingUnit         Currency `json:"min_staking_unit"`
+	BlkReward              Currency `json:"blk_reward"`
+	TxReward               Currency `json:"tx_reward"`
+	PenaltyRatioM          float64  `json:"penalty_ratio_m"`
+	PenaltyRatioL          float64  `json:"penalty_ratio_l"`
+	LazinessWindow         int64    `json:"laziness_window"`
+	LazinessThreshold      int64    `json:"laziness_threshold"`
+	HibernateThreshold     int64    `json:"hibernate_threshold"`
+	HibernatePeriod        int64    `json:"hibernate_period"`
+	BlockBindingWindow     int64    `json:"block_binding_window"`
+	LockupPeriod           int64    `json:"lockup_period"`
+	DraftOpenCount         int64    `json:"draft_open_count"`
+	DraftCloseCount        int64    `json:"draft_close_count"`
+	DraftApplyCount        int64    `json:"draft_apply_count"`
+	DraftDeposit           Currency `json:"draft_deposit"`
+	DraftQuorumRate        float64  `json:"draft_quorum_rate"`
+	DraftPassRate          float64  `json:"draft_pass_rate"`
+	DraftRefundRate        float64  `json:"draft_refund_rate"`
+	UpgradeProtocolHeight  int64    `json:"upgrade_protocol_height"`
+	UpgradeProtocolVersion uint64   `json:"upgrade_protocol_version"`
+}
+
+// MarshalLegacy returns config in the layout before protocol v7, leaving out
+// the fields added since then.
+func (cfg *AMOAppConfig) MarshalLegacy() ([]byte, error) {
+	return json.Marshal(legacyAMOAppConfig{
+		MaxValidators:          cfg.MaxValidators,
+		WeightValidator:        cfg.WeightValidator,
+		WeightDelegator:        cfg.WeightDelegator,
+		MinStakingUnit:         cfg.MinStakingUnit,
+		BlkReward:              cfg.BlkReward,
+		TxReward:               cfg.TxReward,
+		PenaltyRatioM:          cfg.PenaltyRatioM,
+		PenaltyRatioL:          cfg.PenaltyRatioL,
+		LazinessWindow:         cfg.LazinessWindow,
+		LazinessThreshold:      cfg.LazinessThreshold,
+		HibernateThreshold:     cfg.HibernateThreshold,
+		HibernatePeriod:        cfg.HibernatePeriod,
+		BlockBindingWindow:     cfg.BlockBindingWindow,
+		LockupPeriod:           cfg.LockupPeriod,
+		DraftOpenCount:         cfg.DraftOpenCount,
+		DraftCloseCount:        cfg.DraftCloseCount,
+		DraftApplyCount:        cfg.DraftApplyCount,
+		DraftDeposit:           cfg.DraftDeposit,
+		DraftQuorumRate:        cfg.DraftQuorumRate,
+		DraftPassRate:          cfg.DraftPassRate,
+		DraftRefundRate:        cfg.DraftRefundRate,
+		UpgradeProtocolHeight:  cfg.UpgradeProtocolHeight,
+		UpgradeProtocolVersion: cfg.UpgradeProtocolVersion,
+	})
+}
+
+type configBound func(cfg *AMOAppConfig) bool
+
+func inRate(rate float64) bool {
+	return rate >= 0 && rate <= 1
+}
+
+// configBounds holds sane bounds of each config field to be checked when it
+// is changed by a partial config(diff). Fields having no bound map to nil.
+var configBounds = map[string]configBound{
+	"max_validators": func(c *AMOAppConfig) bool {
+		return cmp(c.MaxValidators, ">", uint64(0))
+	},
+	"max_voting_power_rate": func(c *AMOAppConfig) bool {
+		return inRate(c.MaxVotingPowerRate)
+	},
+	"weight_validator": func(c *AMOAppConfig) bool {
+		return cmp(c.WeightValidator, ">", float64(0))
+	},
+	"weight_delegator": func(c *AMOAppConfig) bool {
+		return cmp(c.WeightDelegator, ">", float64(0))
+	},
+	"min_staking_unit": func(c *AMOAppConfig) bool {
+		return cmp(c.MinStakingUnit, ">", *Zero)
+	},
+	"min_self_stake_rate": func(c *AMOAppConfig) bool {
+		return inRate(c.MinSelfStakeRate)
+	},
+	"min_self_stake": func(c *AMOAppConfig) bool {
+		return cmp(c.MinSelfStake, ">=", *Zero)
+	},
+	"blk_reward": func(c *AMOAppConfig) bool {
+		return cmp(c.BlkReward, ">=", *Zero)
+	},
+	"tx_reward": func(c *AMOAppConfig) bool {
+		return cmp(c.TxReward, ">=", *Zero)
+	},
+	"penalty_ratio_m": func(c *AMOAppConfig) bool {
+		return cmp(c.PenaltyRatioM, ">", float64(0)) && inRate(c.PenaltyRatioM)
+	},
+	"penalty_ratio_l": func(c *AMOAppConfig) bool {
+		return cmp(c.PenaltyRatioL, ">", float64(0)) && inRate(c.PenaltyRatioL)
+	},
+	"penalty_ratios": func(c *AMOAppConfig) bool {
+		for _, ratio := range c.PenaltyRatios {
+			if !inRate(ratio) {
+				return false
+			}
+		}
+		return true
+	},
+	"max_evidence_age": func(c *AMOAppConfig) bool {
+		return cmp(c.MaxEvidenceAge, ">=", int64(0))
+	},
+	"penalty_reporter_rate": func(c *AMOAppConfig) bool {
+		return inRate(c.PenaltyReporterRate)
+	},
+	"penalty_to_pool": nil,
+	"laziness_window": func(c *AMOAppConfig) bool {
+		return cmp(c.LazinessWindow, ">=", int64(10000))
+	},
+	"laziness_threshold": func(c *AMOAppConfig) bool {
+		return cmp(c.LazinessThreshold, ">", int64(0))
+	},
+	"hibernate_threshold": func(c *AMOAppConfig) bool {
+		return cmp(c.HibernateThreshold, ">", int64(0))
+	},
+	"hibernate_period": func(c *AMOAppConfig) bool {
+		return cmp(c.HibernatePeriod, ">", int64(0))
+	},
+	"block_binding_window": func(c *AMOAppConfig) bool {
+		return cmp(c.BlockBindingWindow, ">=", int64(10000))
+	},
+	"lockup_period": func(c *AMOAppConfig) bool {
+		return cmp(c.LockupPeriod, ">=", int64(10000))
+	},
+	"early_unlock_penalty_rate": func(c *AMOAppConfig) bool {
+		return cmp(c.EarlyUnlockPenaltyRate, ">=", float64(0)) &&
+			cmp(c.EarlyUnlockPenaltyRate, "<", float64(1))
+	},
+	"early_unlock_penalty_to_pool": nil,
+	"draft_open_count": func(c *AMOAppConfig) bool {
+		return cmp(c.DraftOpenCount, ">=", int64(10000))
+	},
+	"draft_close_count": func(c *AMOAppConfig) bool {
+		return cmp(c.DraftCloseCount, ">=", int64(10000))
+	},
+	"draft_apply_count": func(c *AMOAppConfig) bool {
+		return cmp(c.DraftApplyCount, ">=", int64(10000))
+	},
+	"draft_deposit": func(c *AMOAppConfig) bool {
+		return cmp(c.DraftDeposit, ">=", *Zero)
+	},
+	"draft_quorum_rate": func(c *AMOAppConfig) bool {
+		return cmp(c.DraftQuorumRate, ">", float64(0)) && inRate(c.DraftQuorumRate)
+	},
+	"draft_pass_rate": func(c *AMOAppConfig) bool {
+		return cmp(c.DraftPassRate, ">", float64(0)) && inRate(c.DraftPassRate)
+	},
+	"draft_refund_rate": func(c *AMOAppConfig) bool {
+		return cmp(c.DraftRefundRate, ">", float64(0)) && inRate(c.DraftRefundRate)
+	},
+	"draft_veto_rate": func(c *AMOAppConfig) bool {
+		return inRate(c.DraftVetoRate)
+	},
+	"draft_initial_deposit_rate": func(c *AMOAppConfig) bool {
+		return inRate(c.DraftInitialDepositRate)
+	},
+	"draft_withdraw_penalty_rate": func(c *AMOAppConfig) bool {
+		return inRate(c.DraftWithdrawPenaltyRate)
+	},
+	"draft_max_active": nil,
+	"min_fees": func(c *AMOAppConfig) bool {
+		for _, rate := range c.MinFees {
+			if !cmp(rate.Base, ">=", *Zero) || !cmp(rate.PerByte, ">=", *Zero) ||
+				!cmp(rate.PerCost, ">=", *Zero) {
+				return false
+			}
+		}
+		return true
+	},
+	"max_block_cost": nil,
+	// checked against the current state in CheckDiff()
+	"upgrade_protocol_height":  nil,
+	"upgrade_protocol_version": nil,
+}
+
+// CheckConfigDiff validates a partial config which lists only the fields to
+// be changed. Every field in the diff should be a known one and satisfy its
+// own bound. It returns the names of the fields in ascending order.
+func CheckConfigDiff(diff json.RawMessage) ([]string, error) {
+	var diffMap map[string]json.RawMessage
+	err := json.Unmarshal(diff, &diffMap)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(diffMap))
+	for key := range diffMap {
+		if _, exist := configBounds[key]; !exist {
+			return nil, fmt.Errorf("%s doesn't exist in config map", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var tmpCfg AMOAppConfig
+	dec := json.NewDecoder(bytes.NewReader(diff))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&tmpCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkConfigBounds(&tmpCfg, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	_, existHeight := diffMap["upgrade_protocol_height"]
+	_, existVersion := diffMap["upgrade_protocol_version"]
+	if (existHeight || existVersion) &&
+		!(existHeight && existVersion && len(diffMap) == 2) {
+		return nil, fmt.Errorf("upgrade protocol config should be given alone")
+	}
+
+	return keys, nil
+}
+
+// checkConfigBounds checks the fields of cfg named in keys against their
+// bounds. Keys unknown to configBounds are left to the caller.
+func checkConfigBounds(cfg *AMOAppConfig, keys []string) error {
+	for _, key := range keys {
+		bound := configBounds[key]
+		if bound != nil && !bound(cfg) {
+			return fmt.Errorf("%s: out of bounds", key)
+		}
+	}
+	return nil
+}
+
+// CheckDiff validates a partial config against the current config and state,
+// and returns the config which the diff would make if applied now.
+func (cfg *AMOAppConfig) CheckDiff(
+	blockHeight int64,
+	protocolVersion uint64,
+	diff json.RawMessage,
+) (AMOAppConfig, error) {
+	keys, err := CheckConfigDiff(diff)
+	if err != nil {
+		return AMOAppConfig{}, err
+	}
+
+	tmpCfg := *cfg
+	for _, key := range keys {
+		// replace, not merge into the maps shared with cfg
+		if key == "min_fees" {
+			tmpCfg.MinFees = nil
+		}
+		if key == "penalty_ratios" {
+			tmpCfg.PenaltyRatios = nil
+		}
+	}
+	err = json.Unmarshal(diff, &tmpCfg)
+	if err != nil {
+		return AMOAppConfig{}, err
+	}
+
+	if len(keys) == 2 && keys[0] == "upgrade_protocol_height" {
+		blockHeight += cfg.DraftOpenCount + cfg.DraftCloseCount + cfg.DraftApplyCount
+		protocolVersion += uint64(1)
+
+		if !(tmpCfg.UpgradeProtocolHeight > blockHeight) {
+			return AMOAppConfig{}, fmt.Errorf("%d: improper upgrade protocol height",
+				tmpCfg.UpgradeProtocolHeight,
+			)
+		}
+
+		if !(tmpCfg.UpgradeProtocolVersion == protocolVersion) {
+			return AMOAppConfig{}, fmt.Errorf("%d: improper upgrade protocol version",
+				tmpCfg.UpgradeProtocolVersion,
+			)
+		}
+	}
+
+	return tmpCfg, nil
+}
+
+// MergeConfigDiff overwrites the fields listed in diff on a raw config, while
+// keeping the other fields untouched. The merged config is checked again as a
+// whole, as it is the one to take effect.
+func MergeConfigDiff(cfgRaw, diff []byte) ([]byte, error) {
+	cfgMap := map[string]json.RawMessage{}
+	if len(cfgRaw) > 0 {
+		err := json.Unmarshal(cfgRaw, &cfgMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var diffMap map[string]json.RawMessage
+	err := json.Unmarshal(diff, &diffMap)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range diffMap {
+		cfgMap[key] = value
+	}
+
+	b, err := json.Marshal(cfgMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged AMOAppConfig
+	err = json.Unmarshal(b, &merged)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(cfgMap))
+	for key := range cfgMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	err = checkConfigBounds(&merged, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
 func (cfg *AMOAppConfig) getMap() (map[string]interface{}, error) {
 	var mapConfig map[string]interface{}
 
@@ -46,6 +46,15 @@ func TestConfigCheckValue(t *testing.T) {
 	_, err = cfg.Check(height, protocolVersion, payload)
 	assert.Error(t, err)
 
+	// fields added since protocol v7 are changed only by a partial config
+	payload = []byte(`{"draft_veto_rate": 0.1}`)
+	_, err = cfg.Check(height, protocolVersion, payload)
+	assert.Error(t, err)
+
+	payload = []byte(`{"max_block_cost": 100000}`)
+	_, err = cfg.Check(height, protocolVersion, payload)
+	assert.Error(t, err)
+
 	payload = []byte(`{"lockup_period": 100000}`)
 	changedCfg, err := cfg.Check(height, protocolVersion, payload)
 	assert.NoError(t, err)
@@ -88,3 +97,107 @@ func TestConfigCheckValue(t *testing.T) {
 	assert.NotEqual(t, changedCfg.UpgradeProtocolHeight, cfg.UpgradeProtocolHeight)
 	assert.NotEqual(t, changedCfg.UpgradeProtocolVersion, cfg.UpgradeProtocolVersion)
 }
+
+func TestConfigDiff(t *testing.T) {
+	cfg, err := NewDefaultAMOAppConfig()
+	assert.NoError(t, err)
+
+	// every config field should have its entry in configBounds
+	full := cfg
+	// omitted if empty
+	full.MinFees = map[string]FeeRate{"transfer": {}}
+	full.PenaltyRatios = map[string]float64{"duplicate/vote": 0.5}
+	full.MaxBlockCost = 1
+	cfgMap, err := full.getMap()
+	assert.NoError(t, err)
+	assert.Equal(t, len(cfgMap), len(configBounds))
+	for key := range cfgMap {
+		_, exist := configBounds[key]
+		assert.True(t, exist, key)
+	}
+
+	height := int64(1)
+	protocolVersion := uint64(7)
+
+	_, err = CheckConfigDiff([]byte(`{"non_existing_config": "0"}`))
+	assert.Error(t, err)
+	_, err = CheckConfigDiff([]byte(`{"draft_pass_rate": 1.5}`))
+	assert.Error(t, err)
+	_, err = CheckConfigDiff([]byte(`{"penalty_ratio_m": 0}`))
+	assert.Error(t, err)
+	_, err = CheckConfigDiff([]byte(`{"penalty_ratios": {"duplicate/vote": 1.5}}`))
+	assert.Error(t, err)
+	_, err = CheckConfigDiff([]byte(`{"max_validators": "many"}`))
+	assert.Error(t, err)
+	_, err = CheckConfigDiff([]byte(`{"blk_reward": "1", "upgrade_protocol_height": 100000}`))
+	assert.Error(t, err)
+
+	keys, err := CheckConfigDiff([]byte(`{"tx_reward": "0", "blk_reward": "100"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"blk_reward", "tx_reward"}, keys)
+
+	changedCfg, err := cfg.CheckDiff(height, protocolVersion, []byte(`{"blk_reward": "100"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, new(Currency).Set(100), &changedCfg.BlkReward)
+	assert.Equal(t, cfg.TxReward, changedCfg.TxReward)
+
+	_, err = cfg.CheckDiff(height, protocolVersion,
+		[]byte(`{"upgrade_protocol_height": 10, "upgrade_protocol_version": 8}`))
+	assert.Error(t, err)
+	_, err = cfg.CheckDiff(height, protocolVersion,
+		[]byte(`{"upgrade_protocol_height": 100000, "upgrade_protocol_version": 8}`))
+	assert.NoError(t, err)
+
+	// diffs proposed from the same snapshot don't revert each other
+	cfgRaw := []byte(`{"blk_reward":"0","tx_reward":"0","lockup_period":10000}`)
+	cfgRaw, err = MergeConfigDiff(cfgRaw, []byte(`{"blk_reward":"100"}`))
+	assert.NoError(t, err)
+	cfgRaw, err = MergeConfigDiff(cfgRaw, []byte(`{"tx_reward":"200"}`))
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`{"blk_reward":"100","lockup_period":10000,"tx_reward":"200"}`,
+		string(cfgRaw))
+
+	// merged config is checked as a whole
+	_, err = MergeConfigDiff(cfgRaw, []byte(`{"lockup_period":100}`))
+	assert.Error(t, err)
+	_, err = MergeConfigDiff([]byte(`{"draft_veto_rate":2}`),
+		[]byte(`{"tx_reward":"200"}`))
+	assert.Error(t, err)
+	_, err = MergeConfigDiff(cfgRaw, []byte(`{"max_validators":"many"}`))
+	assert.Error(t, err)
+}
+
+func TestConfigMinFees(t *testing.T) {
+	cfg, err := NewDefaultAMOAppConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, cfg.MinFees)
+
+	_, err = CheckConfigDiff([]byte(`{"min_fees": {"transfer": {"base": "-1"}}}`))
+	assert.Error(t, err)
+
+	cfg, err = cfg.CheckDiff(1, 7, []byte(`{"min_fees": {
+		"transfer": {"base": "100", "per_byte": "2", "per_cost": "3"},
+		"stake": {"base": "100"}
+	}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(cfg.MinFees))
+	assert.Equal(t, new(Currency).Set(100), cfg.MinFees["transfer"].Fee(0, 0))
+	assert.Equal(t, new(Currency).Set(120), cfg.MinFees["transfer"].Fee(10, 0))
+	assert.Equal(t, new(Currency).Set(150), cfg.MinFees["transfer"].Fee(10, 10))
+	assert.Equal(t, new(Currency).Set(100), cfg.MinFees["stake"].Fee(10, 10))
+
+	// schedule gets replaced as a whole
+	changedCfg, err := cfg.CheckDiff(1, 7,
+		[]byte(`{"min_fees": {"transfer": {"base": "10"}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changedCfg.MinFees))
+	assert.Equal(t, 2, len(cfg.MinFees))
+
+	_, err = CheckConfigDiff([]byte(`{"min_fees": {"transfer": {"per_cost": "-1"}}}`))
+	assert.Error(t, err)
+
+	changedCfg, err = cfg.CheckDiff(1, 7, []byte(`{"max_block_cost": 100000}`))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100000), changedCfg.MaxBlockCost)
+}
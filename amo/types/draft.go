@@ -6,10 +6,32 @@ import (
 	"github.com/tendermint/tendermint/crypto"
 )
 
+const (
+	DraftKindConfig  = "config" // default
+	DraftKindText    = "text"
+	DraftKindSpend   = "spend"
+	DraftKindUpgrade = "upgrade"
+)
+
+// Draft.Diff, if not empty, lists only the config fields to change and gets
+// merged into the config in effect at the time of application. Otherwise,
+// Draft.Config replaces the whole config.
+//
+// Text drafts change nothing when applied, spend drafts pay Amount to
+// Recipient from the community pool, and upgrade drafts schedule Plan.
+//
+// Draft.MinDeposit, if set, is the deposit the draft should collect from its
+// proposer and co-sponsors until its vote opens. Otherwise, the draft gets
+// dropped and the deposits are returned.
 type Draft struct {
-	Proposer crypto.Address `json:"proposer"`
-	Config   AMOAppConfig   `json:"config"`
-	Desc     string         `json:"desc"`
+	Proposer  crypto.Address  `json:"proposer"`
+	Kind      string          `json:"kind,omitempty"`
+	Config    AMOAppConfig    `json:"config"`
+	Diff      json.RawMessage `json:"diff,omitempty"`
+	Recipient crypto.Address  `json:"recipient,omitempty"`
+	Amount    *Currency       `json:"amount,omitempty"`
+	Plan      *UpgradePlan    `json:"plan,omitempty"`
+	Desc      string          `json:"desc"`
 
 	OpenCount  int64    `json:"open_count"`
 	CloseCount int64    `json:"close_count"`
@@ -19,6 +41,10 @@ type Draft struct {
 	TallyQuorum  Currency `json:"tally_quorum"`
 	TallyApprove Currency `json:"tally_approve"`
 	TallyReject  Currency `json:"tally_reject"`
+	// since AMOProtocolV7
+	TallyAbstain *Currency `json:"tally_abstain,omitempty"`
+	TallyVeto    *Currency `json:"tally_veto,omitempty"`
+	MinDeposit   *Currency `json:"min_deposit,omitempty"`
 }
 
 // DraftForQuery structure is an alternative one to contain AMOAppConfig
@@ -33,9 +59,14 @@ type Draft struct {
 // should be treated with DraftForQuery structure.
 
 type DraftForQuery struct {
-	Proposer crypto.Address  `json:"proposer"`
-	Config   json.RawMessage `json:"config"`
-	Desc     string          `json:"desc"`
+	Proposer  crypto.Address  `json:"proposer"`
+	Kind      string          `json:"kind,omitempty"`
+	Config    json.RawMessage `json:"config"`
+	Diff      json.RawMessage `json:"diff,omitempty"`
+	Recipient crypto.Address  `json:"recipient,omitempty"`
+	Amount    *Currency       `json:"amount,omitempty"`
+	Plan      *UpgradePlan    `json:"plan,omitempty"`
+	Desc      string          `json:"desc"`
 
 	OpenCount  int64    `json:"open_count"`
 	CloseCount int64    `json:"close_count"`
@@ -45,9 +76,37 @@ type DraftForQuery struct {
 	TallyQuorum  Currency `json:"tally_quorum"`
 	TallyApprove Currency `json:"tally_approve"`
 	TallyReject  Currency `json:"tally_reject"`
+	// since AMOProtocolV7
+	TallyAbstain *Currency `json:"tally_abstain,omitempty"`
+	TallyVeto    *Currency `json:"tally_veto,omitempty"`
+	MinDeposit   *Currency `json:"min_deposit,omitempty"`
 }
 
 type DraftEx struct {
 	*DraftForQuery
-	Votes []*VoteInfo `json:"votes"`
+	Votes    []*VoteInfo    `json:"votes"`
+	Deposits []*DepositInfo `json:"deposits,omitempty"`
+	Tally    *DraftTally    `json:"tally,omitempty"`
+}
+
+// DraftTally is the result of counting votes on a draft. While the vote is
+// open, it is a projection made from the current votes and stakes.
+type DraftTally struct {
+	Quorum  Currency `json:"quorum"`
+	Approve Currency `json:"approve"`
+	Reject  Currency `json:"reject"`
+	Abstain Currency `json:"abstain"`
+	Veto    Currency `json:"veto"`
+	Total   Currency `json:"total"`
+
+	QuorumReached bool `json:"quorum_reached"`
+	Vetoed        bool `json:"vetoed"`
+	Refund        bool `json:"refund"`
+	Passed        bool `json:"passed"`
+}
+
+// DepositInfo is a deposit added to a draft by a co-sponsor.
+type DepositInfo struct {
+	Depositor crypto.Address `json:"depositor"`
+	Amount    Currency       `json:"amount"`
 }
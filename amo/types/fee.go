@@ -0,0 +1,24 @@
+package types
+
+import (
+	"math/big"
+)
+
+// FeeRate is the minimum fee of a tx type, which grows with the size of the
+// tx payload and the cost of executing the tx.
+type FeeRate struct {
+	Base    Currency `json:"base"`
+	PerByte Currency `json:"per_byte"`
+	PerCost Currency `json:"per_cost"`
+}
+
+// Fee returns the minimum fee of a tx carrying a payload of the size given
+// and costing the execution cost given.
+func (r FeeRate) Fee(size int, cost uint64) *Currency {
+	fee := new(Currency)
+	fee.Int.Mul(&r.PerByte.Int, big.NewInt(int64(size)))
+	fee.Add(&r.Base)
+	perCost := new(Currency)
+	perCost.Int.Mul(&r.PerCost.Int, new(big.Int).SetUint64(cost))
+	return fee.Add(perCost)
+}
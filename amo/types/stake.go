@@ -31,3 +31,26 @@ func (s StakeEx) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(v)
 }
+
+// SelfStakeRule is the minimum self-stake a validator must keep, as a ratio to
+// its effective stake and as an absolute amount.
+type SelfStakeRule struct {
+	Rate   float64
+	Amount Currency
+}
+
+// LockedStake is a lockup tranche of a stake. It is going to be unlocked and
+// reported via a `stake_unlock` event at UnlockHeight, i.e. after Remaining
+// more blocks.
+type LockedStake struct {
+	Amount       Currency `json:"amount"`
+	Remaining    int64    `json:"remaining"`
+	UnlockHeight int64    `json:"unlock_height"`
+}
+
+type LockedStakeEx struct {
+	Validator    bytes.HexBytes `json:"validator"`
+	Unlocked     Currency       `json:"unlocked"`
+	Locked       Currency       `json:"locked"`
+	LockedStakes []*LockedStake `json:"locked_stakes"`
+}
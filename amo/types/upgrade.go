@@ -0,0 +1,11 @@
+package types
+
+// UpgradePlan schedules a protocol upgrade at a certain height. Nodes running
+// a software which does not support the protocol version halt at the height.
+type UpgradePlan struct {
+	Name       string `json:"name"`
+	Height     int64  `json:"height"`
+	Info       string `json:"info,omitempty"`
+	Version    uint64 `json:"protocol_version"`
+	AppVersion string `json:"app_version"` // software version required
+}
@@ -0,0 +1,16 @@
+package types
+
+import (
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/libs/bytes"
+)
+
+// VotingPower shows how a validator's voting power is derived from its
+// effective stake. Power is the one before applying the voting power cap,
+// and CappedPower is the one actually used in the consensus.
+type VotingPower struct {
+	Validator   bytes.HexBytes `json:"validator"`
+	Holder      crypto.Address `json:"holder"`
+	Power       int64          `json:"power"`
+	CappedPower int64          `json:"capped_power"`
+}
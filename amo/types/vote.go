@@ -4,8 +4,36 @@ import (
 	"github.com/tendermint/tendermint/crypto"
 )
 
+const (
+	VoteOptionYes     = "yes"
+	VoteOptionNo      = "no"
+	VoteOptionAbstain = "abstain"
+	VoteOptionVeto    = "no_with_veto"
+)
+
+// Vote.Option is given since AMOProtocolV7. Votes without an option are
+// treated as yes or no according to Vote.Approve.
 type Vote struct {
-	Approve bool `json:"approve"`
+	Approve bool   `json:"approve"`
+	Option  string `json:"option,omitempty"`
+}
+
+func (v *Vote) GetOption() string {
+	if len(v.Option) > 0 {
+		return v.Option
+	}
+	if v.Approve {
+		return VoteOptionYes
+	}
+	return VoteOptionNo
+}
+
+func IsValidVoteOption(option string) bool {
+	switch option {
+	case VoteOptionYes, VoteOptionNo, VoteOptionAbstain, VoteOptionVeto:
+		return true
+	}
+	return false
 }
 
 type VoteInfo struct {
@@ -2,13 +2,16 @@ package amo
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
 	tmdb "github.com/tendermint/tm-db"
 
 	"github.com/amolabs/amoabci/amo/code"
+	astore "github.com/amolabs/amoabci/amo/store"
 	"github.com/amolabs/amoabci/amo/types"
 )
 
@@ -42,8 +45,8 @@ func TestProtocolConfigMigration(t *testing.T) {
 	jsonStr, _ := json.Marshal(configV3)
 	app.store.SetAppConfig(jsonStr)
 	app.store.SetProtocolVersion(0) // this will be overriden by app.load()
-	app.store.Save() // save height 1
-	app.store.Save() // save height 2
+	app.store.Save()                // save height 1
+	app.store.Save()                // save height 2
 
 	app.load() // assume restart took place here
 	assert.Equal(t, int64(3), app.store.GetMerkleVersion())
@@ -54,7 +57,7 @@ func TestProtocolConfigMigration(t *testing.T) {
 	// simulate protocol upgrade from v3 to v4.
 	// save protocol 3 config
 	var configV4 struct {
-		LazinessWindow  int64  `json:"laziness_window"`
+		LazinessWindow         int64  `json:"laziness_window"`
 		UpgradeProtocolHeight  int64  `json:"upgrade_protocol_height"`
 		UpgradeProtocolVersion uint64 `json:"upgrade_protocol_version"`
 	}
@@ -152,20 +155,312 @@ func TestProtocolUpgrade(t *testing.T) {
 	app.config.UpgradeProtocolVersion = 0x7
 
 	// protocol 6 -> 7
+	assert.Equal(t, "", app.store.GetChainID(false))
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{
+		ChainID: "amo-test",
+		Height:  12,
+	}})
+	// now protocol version 7
+	assert.Equal(t, uint64(0x7), app.state.ProtocolVersion)
+	assert.NotNil(t, app.proto)
+	assert.Equal(t, uint64(0x7), app.proto.Version())
+	// chain id gets recorded
+	assert.Equal(t, "amo-test", app.store.GetChainID(false))
+	//
+	app.EndBlock(abci.RequestEndBlock{Height: 12})
+	app.Commit()
+
+	app.config.UpgradeProtocolHeight = 13
+	app.config.UpgradeProtocolVersion = 0x8
+
+	// protocol 7 -> 8
 	// The following will panic, so we will use a different testing point.
 	//b, err = json.Marshal(app.config)
 	//assert.NoError(t, err)
 	//err = app.store.SetAppConfig(b)
 	//assert.NoError(t, err)
-	//app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 12}})
-	//app.EndBlock(abci.RequestEndBlock{Height: 12})
+	//app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 13}})
+	//app.EndBlock(abci.RequestEndBlock{Height: 13})
 	//app.Commit()
-	app.state.Height = 12
+	app.state.Height = 13
 	app.upgradeProtocol()
 
-	assert.Equal(t, uint64(0x7), app.state.ProtocolVersion)
+	assert.Equal(t, uint64(0x8), app.state.ProtocolVersion)
 	assert.Nil(t, app.proto)
 	err = checkProtocolVersion(app.state.ProtocolVersion)
-	assert.Error(t, err) // protocol version 7 is not supported
+	assert.Error(t, err) // protocol version 8 is not supported
+}
+
+func TestUpgradePlan(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	halted := ""
+	app.halt = func(msg string) { halted = msg }
+
+	app.state.ProtocolVersion = 0x6
+	app.store.SetUpgradePlan(&types.UpgradePlan{
+		Name:       "v7",
+		Height:     3,
+		Version:    0x7,
+		AppVersion: "v1.10.x",
+	})
+	app.store.Save()
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	assert.Equal(t, uint64(0x6), app.state.ProtocolVersion)
+	app.EndBlock(abci.RequestEndBlock{Height: 2})
+	app.Commit()
+
+	// protocol 6 -> 7 by plan
+	res2 := app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 3}})
+	assert.Equal(t, uint64(0x7), app.state.ProtocolVersion)
+	assert.Equal(t, uint64(0x7), app.proto.Version())
+	assert.Equal(t, "protocol_upgrade", res2.Events[0].Type)
+	assert.Equal(t, "", halted)
+	app.EndBlock(abci.RequestEndBlock{Height: 3})
+	app.Commit()
+
+	res := app.Query(abci.RequestQuery{Path: "/upgrade"})
+	assert.Equal(t, code.QueryCodeNoMatch, res.Code)
+
+	// protocol 7 -> 8: not supported by this software
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 4}})
+	app.store.SetUpgradePlan(&types.UpgradePlan{
+		Name:       "v8",
+		Height:     5,
+		Version:    0x8,
+		AppVersion: "v1.11.x",
+	})
+	app.EndBlock(abci.RequestEndBlock{Height: 4})
+	app.Commit()
+	res = app.Query(abci.RequestQuery{Path: "/upgrade"})
+	assert.Equal(t, code.QueryCodeOK, res.Code)
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 5}})
+	assert.Equal(t, uint64(0x7), app.state.ProtocolVersion)
+	assert.Contains(t, halted, `UPGRADE "v8" NEEDED at height 5`)
+	assert.Contains(t, halted, "v1.11.x")
+}
+
+func copyDB(t *testing.T, src tmdb.DB) tmdb.DB {
+	dst := tmdb.NewMemDB()
+	itr, err := src.Iterator(nil, nil)
+	assert.NoError(t, err)
+	for ; itr.Valid(); itr.Next() {
+		dst.Set(itr.Key(), itr.Value())
+	}
+	itr.Close()
+	return dst
+}
+
+// testProtocolV7 replaces the migration of v7 for tests
+type testProtocolV7 struct {
+	AMOProtocolV7
+	migration *ProtocolMigration
+}
+
+func (proto *testProtocolV7) Migration() *ProtocolMigration {
+	return proto.migration
+}
+
+func setTestMigrationV7(migration ProtocolMigration) {
+	AMOProtocolVersions[uint64(0x7)] = &testProtocolV7{migration: &migration}
+}
+
+func resetMigrationV7() {
+	AMOProtocolVersions[uint64(0x7)] = &AMOProtocolV7{}
+}
+
+func TestProtocolMigration(t *testing.T) {
+	addr := makeAccAddr("migration")
+	runs := 0
+	setTestMigrationV7(ProtocolMigration{
+		Changes: []string{"give some coins"},
+		Operation: func(s *astore.Store, st State, logger log.Logger) error {
+			runs += 1
+			return s.SetBalance(addr, new(types.Currency).Set(100))
+		},
+	})
+	defer resetMigrationV7()
+
+	mdb := tmdb.NewMemDB()
+	app := NewAMOApp(1, mdb, tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x6
+	app.store.SetProtocolVersion(0x6)
+	app.config.UpgradeProtocolHeight = 2
+	app.config.UpgradeProtocolVersion = 0x7
+	b, err := json.Marshal(app.config)
+	assert.NoError(t, err)
+	assert.NoError(t, app.store.SetAppConfig(b))
+	app.store.Save()
+
+	// offline against a copy of the merkle DB
+	s, err := astore.NewStore(nil, 1, copyDB(t, mdb), tmdb.NewMemDB())
+	assert.NoError(t, err)
+	_, err = s.Load()
+	assert.NoError(t, err)
+	before, after, err := RunMigration(s, State{}, log.NewNopLogger(), 0x7)
+	assert.NoError(t, err)
+	assert.NotEqual(t, before, after)
+	assert.Equal(t, new(types.Currency).Set(100), s.GetBalance(addr, false))
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, new(types.Currency).Set(0), app.store.GetBalance(addr, false))
+
+	// no migration registered
+	before, after, err = RunMigration(s, State{}, log.NewNopLogger(), 0x6)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	// on the live chain
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	assert.Equal(t, uint64(0x7), app.state.ProtocolVersion)
+	assert.Equal(t, 2, runs)
+	assert.Equal(t, new(types.Currency).Set(100), app.store.GetBalance(addr, false))
+	app.EndBlock(abci.RequestEndBlock{Height: 2})
+	app.Commit()
+
+	// reload at the upgrade height: no migration again
+	app = NewAMOApp(1, mdb, tmdb.NewMemDB(), nil)
+	assert.Equal(t, uint64(0x7), app.state.ProtocolVersion)
+	app.state.Height = 2
+	app.upgradeProtocol()
+	assert.Equal(t, uint64(0x7), app.state.ProtocolVersion)
+	assert.Equal(t, 2, runs)
+
+	// migration failure halts the node
+	setTestMigrationV7(ProtocolMigration{
+		Operation: func(s *astore.Store, st State, logger log.Logger) error {
+			return errors.New("broken")
+		},
+	})
+	app = NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	halted := ""
+	app.halt = func(msg string) { halted = msg }
+	app.state.ProtocolVersion = 0x6
+	app.store.SetProtocolVersion(0x6)
+	app.config.UpgradeProtocolHeight = 1
+	app.config.UpgradeProtocolVersion = 0x7
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	assert.Equal(t, uint64(0x6), app.state.ProtocolVersion)
+	assert.Contains(t, halted, "broken")
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	addr := makeAccAddr("migration")
+	setTestMigrationV7(ProtocolMigration{
+		Changes: []string{"give some coins"},
+		Operation: func(s *astore.Store, st State, logger log.Logger) error {
+			return s.SetBalance(addr, new(types.Currency).Set(100))
+		},
+	})
+	defer resetMigrationV7()
+
+	mdb := tmdb.NewMemDB()
+	idxdb := tmdb.NewMemDB()
+	app := NewAMOApp(1, mdb, idxdb, nil)
+	app.store.SetProtocolVersion(0x6)
+	app.store.SetBalance(makeAccAddr("alice"), new(types.Currency).Set(10))
+	app.store.Save()
+	app.store.SetBalance(makeAccAddr("bob"), new(types.Currency).Set(10))
+	hash, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	// protocol version not supported
+	_, err = MigrateDryRun(copyDB(t, mdb), copyDB(t, idxdb), 0, 0x8, "", nil)
+	assert.Error(t, err)
+
+	// at the latest height
+	report, err := MigrateDryRun(copyDB(t, mdb), copyDB(t, idxdb), 0, 0x7, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), report.Height)
+	assert.Equal(t, []byte(hash), report.ChecksumBefore)
+	assert.NotEqual(t, report.ChecksumBefore, report.AppHash)
+	assert.Equal(t, map[string]int{"balance:": 1, "protocol": 1}, report.Changes)
+	assert.Equal(t, "", report.Error)
+
+	// at a height pruned out
+	_, err = MigrateDryRun(copyDB(t, mdb), copyDB(t, idxdb), 1, 0x7, "", nil)
+	assert.Error(t, err)
+
+	// migration failure
+	setTestMigrationV7(ProtocolMigration{
+		Operation: func(s *astore.Store, st State, logger log.Logger) error {
+			return errors.New("broken")
+		},
+	})
+	report, err = MigrateDryRun(copyDB(t, mdb), copyDB(t, idxdb), 0, 0x7, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "broken", report.Error)
+
+	// the original DBs are left untouched
+	assert.Equal(t, new(types.Currency).Set(0), app.store.GetBalance(addr, true))
+	assert.Equal(t, []byte(hash), app.store.Root())
 }
 
+func TestMigrateDryRunV7(t *testing.T) {
+	mdb := tmdb.NewMemDB()
+	idxdb := tmdb.NewMemDB()
+	app := NewAMOApp(1, mdb, idxdb, nil)
+	app.store.SetProtocolVersion(0x6)
+	app.store.SetDraft(1, &types.Draft{
+		Proposer:   makeAccAddr("proposer"),
+		Kind:       types.DraftKindText,
+		CloseCount: 10,
+		ApplyCount: 10,
+	})
+	hash, _, err := app.store.Save()
+	assert.NoError(t, err)
+
+	dryMdb := copyDB(t, mdb)
+	report, err := MigrateDryRun(dryMdb, copyDB(t, idxdb), 0, 0x7, "amo-test", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(hash), report.ChecksumBefore)
+	assert.Equal(t, map[string]int{
+		"chain_id":      1,
+		"active_drafts": 1,
+		"protocol":      1,
+	}, report.Changes)
+
+	// app hash is the one committed
+	s, err := astore.NewStore(nil, 1, dryMdb, tmdb.NewMemDB())
+	assert.NoError(t, err)
+	_, err = s.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, report.AppHash, s.Root())
+	assert.Equal(t, "amo-test", s.GetChainID(true))
+	assert.Equal(t, []uint32{1}, s.GetActiveDraftIDs(true))
+}
+
+func TestUpgradeActiveDraft(t *testing.T) {
+	app := NewAMOApp(1, tmdb.NewMemDB(), tmdb.NewMemDB(), nil)
+	app.state.ProtocolVersion = 0x6
+	app.store.SetProtocolVersion(0x6)
+	app.proto = AMOProtocolVersions[0x6]
+	app.config.UpgradeProtocolHeight = 2
+	app.config.UpgradeProtocolVersion = 0x7
+	b, err := json.Marshal(app.config)
+	assert.NoError(t, err)
+	assert.NoError(t, app.store.SetAppConfig(b))
+	app.store.SetDraft(1, &types.Draft{
+		Proposer:   makeAccAddr("proposer"),
+		Kind:       types.DraftKindText,
+		CloseCount: 10,
+		ApplyCount: 10,
+	})
+	app.state.NextDraftID = 2
+	app.state.ChainID = "amo-test"
+
+	// the latest draft is processed without getting listed
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+	assert.Equal(t, int64(9), app.store.GetDraft(1, false).CloseCount)
+	assert.Equal(t, 0, len(app.store.GetActiveDraftIDs(false)))
+
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	assert.Equal(t, uint64(0x7), app.state.ProtocolVersion)
+	assert.Equal(t, []uint32{1}, app.store.GetActiveDraftIDs(false))
+	assert.Equal(t, "amo-test", app.store.GetChainID(false))
+	assert.NotNil(t, app.proto.Migration())
+	app.EndBlock(abci.RequestEndBlock{Height: 2})
+	assert.Equal(t, int64(8), app.store.GetDraft(1, false).CloseCount)
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/tendermint/tendermint/libs/log"
+	tmtypes "github.com/tendermint/tendermint/types"
+	tmdb "github.com/tendermint/tm-db"
+
+	"github.com/amolabs/amoabci/amo"
+)
+
+const copyBatchSize = 10000
+
+var MigrateDryRunCmd = &cobra.Command{
+	Use:   "migrate-dry-run",
+	Short: "Rehearse a protocol migration on a copy of the node's DBs",
+	Long: "Copy the merkle and index DBs of a stopped node, and run the " +
+		"migration of the target protocol version at the height given. " +
+		"The node's DBs are left untouched.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := readConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		height, err := cmd.Flags().GetInt64("height")
+		if err != nil {
+			return err
+		}
+		version, err := cmd.Flags().GetUint64("protocol-version")
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			return fmt.Errorf("protocol version not given")
+		}
+		outDirPath, err := cmd.Flags().GetString("out")
+		if err != nil {
+			return err
+		}
+		if len(outDirPath) == 0 {
+			outDirPath, err = ioutil.TempDir("", "amod-migrate-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(outDirPath)
+		}
+
+		// logger
+		logger := log.NewFilter(
+			log.NewTMLogger(log.NewSyncWriter(os.Stderr)),
+			log.AllowInfo(),
+		)
+
+		// chain id is not recorded in the store before protocol v7
+		genDoc, err := tmtypes.GenesisDocFromFile(config.GenesisFile())
+		if err != nil {
+			return err
+		}
+
+		dataDirPath := filepath.Join(config.RootDir, config.DataDir)
+		backend := tmdb.BackendType(config.DBBackend)
+		merkleDB, err := copyDB(config.MerkleDB, backend, dataDirPath, outDirPath)
+		if err != nil {
+			return err
+		}
+		defer merkleDB.Close()
+		indexDB, err := copyDB(config.IndexDB, backend, dataDirPath, outDirPath)
+		if err != nil {
+			return err
+		}
+		defer indexDB.Close()
+
+		report, err := amo.MigrateDryRun(merkleDB, indexDB, height, version,
+			genDoc.ChainID, logger.With("module", "migrate"))
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+
+		if len(report.Error) > 0 {
+			return fmt.Errorf("migration failed: %s", report.Error)
+		}
+
+		return nil
+	},
+}
+
+// openSourceDB opens the DB in dir without creating it, and read-only where
+// the backend supports it.
+func openSourceDB(name string, backend tmdb.BackendType, dir string) (
+	tmdb.DB, error) {
+	_, err := os.Stat(filepath.Join(dir, name+".db"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s DB: %s", name, err.Error())
+	}
+	if backend == tmdb.GoLevelDBBackend {
+		db, err := tmdb.NewGoLevelDBWithOpts(name, dir, &opt.Options{
+			ReadOnly:       true,
+			ErrorIfMissing: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+	return tmdb.NewDB(name, backend, dir), nil
+}
+
+// copyDB copies the DB in srcDir to dstDir, and returns the copied one.
+func copyDB(name string, backend tmdb.BackendType, srcDir, dstDir string) (
+	tmdb.DB, error) {
+	src, err := openSourceDB(name, backend, srcDir)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	dst := tmdb.NewDB(name, backend, dstDir)
+
+	itr, err := src.Iterator(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+	batch := dst.NewBatch()
+	n := 0
+	for ; itr.Valid(); itr.Next() {
+		batch.Set(itr.Key(), itr.Value())
+		n += 1
+		if n%copyBatchSize == 0 {
+			err = batch.Write()
+			batch.Close()
+			if err != nil {
+				return nil, err
+			}
+			batch = dst.NewBatch()
+		}
+	}
+	err = batch.WriteSync()
+	batch.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+func init() {
+	MigrateDryRunCmd.Flags().Int64("height", 0,
+		"upgrade height to rehearse at (0 for the height next to the latest)")
+	MigrateDryRunCmd.Flags().Uint64("protocol-version", 0,
+		"target protocol version")
+	MigrateDryRunCmd.Flags().String("out", "",
+		"directory to keep the migrated DBs (temporary if not given)")
+}
@@ -29,36 +29,7 @@ var RunCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Execute the daemon",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// get default config
-		config := cfg.DefaultConfig()
-
-		// parse flags
-		amoDirPath, err := cmd.Flags().GetString("home")
-		if err != nil {
-			return err
-		}
-
-		// set root dir
-		config.SetRoot(amoDirPath)
-		tmCfg.EnsureRoot(config.RootDir)
-
-		// parse and validate config
-		configFile := filepath.Join(
-			config.RootDir,
-			config.ConfigDir,
-			cfg.DefaultConfigFileName,
-		)
-		vp := viper.New()
-		vp.SetConfigFile(configFile)
-		err = vp.ReadInConfig()
-		if err != nil {
-			return err
-		}
-		err = vp.UnmarshalExact(config)
-		if err != nil {
-			return err
-		}
-		err = config.ValidateBasic()
+		config, err := readConfig(cmd)
 		if err != nil {
 			return err
 		}
@@ -142,6 +113,44 @@ var RunCmd = &cobra.Command{
 	},
 }
 
+func readConfig(cmd *cobra.Command) (*cfg.Config, error) {
+	// get default config
+	config := cfg.DefaultConfig()
+
+	// parse flags
+	amoDirPath, err := cmd.Flags().GetString("home")
+	if err != nil {
+		return nil, err
+	}
+
+	// set root dir
+	config.SetRoot(amoDirPath)
+	tmCfg.EnsureRoot(config.RootDir)
+
+	// parse and validate config
+	configFile := filepath.Join(
+		config.RootDir,
+		config.ConfigDir,
+		cfg.DefaultConfigFileName,
+	)
+	vp := viper.New()
+	vp.SetConfigFile(configFile)
+	err = vp.ReadInConfig()
+	if err != nil {
+		return nil, err
+	}
+	err = vp.UnmarshalExact(config)
+	if err != nil {
+		return nil, err
+	}
+	err = config.ValidateBasic()
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
 func initApp(config *cfg.Config, logger log.Logger) (*amo.AMOApp, error) {
 	dataDirPath := filepath.Join(config.RootDir, config.DataDir)
 
@@ -14,6 +14,7 @@ import (
 /* Commands (expected hierarchy)
  *
  * amod |- run
+ *      |- migrate-dry-run
  *      |- tendermint
  */
 
@@ -22,6 +23,7 @@ func main() {
 
 	rootCmd := cmd.RootCmd
 	runCmd := cmd.RunCmd
+	migrateCmd := cmd.MigrateDryRunCmd
 	tmCmd := tm.RootCmd
 	tmCmd.AddCommand(
 		tm.GenValidatorCmd,
@@ -40,9 +42,11 @@ func main() {
 	)
 
 	cli.PrepareBaseCmd(runCmd, "AMO", cfg.DefaultAMODirPath)
+	cli.PrepareBaseCmd(migrateCmd, "AMO", cfg.DefaultAMODirPath)
 	cli.PrepareBaseCmd(tmCmd, "AMO", cfg.DefaultAMODirPath)
 
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(tmCmd)
 
 	if err := rootCmd.Execute(); err != nil {
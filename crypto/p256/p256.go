@@ -16,6 +16,9 @@ import (
 var (
 	c = elliptic.P256()
 	h = tmc.Sha256
+	// signatures with s greater than this are rejected, as (r, n-s) is also
+	// a valid signature for (r, s)
+	halfOrder = new(big.Int).Rsh(c.Params().N, 1)
 )
 
 const (
@@ -63,12 +66,39 @@ func (privKey PrivKeyP256) ToECDSA() *ecdsa.PrivateKey {
 	}
 }
 
+// Sign signs msg with a deterministic nonce (RFC 6979), and always returns a
+// signature in low-S form.
 func (privKey PrivKeyP256) Sign(msg []byte) ([]byte, error) {
-	priv := privKey.ToECDSA()
-	r, s, err := ecdsa.Sign(tmc.CReader(), priv, h(msg))
-	if err != nil {
-		return nil, err
+	n := c.Params().N
+	d := new(big.Int).Mod(new(big.Int).SetBytes(privKey[:]), n)
+	if d.Sign() == 0 {
+		return nil, errors.New("Invalid private key")
+	}
+	hash := h(msg)
+	e := new(big.Int).SetBytes(hash)
+
+	var r, s *big.Int
+	nonce := nonceRFC6979(d, hash)
+	for {
+		k := nonce()
+		x, _ := c.ScalarBaseMult(k.Bytes())
+		r = new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = k^-1 * (e + r*d) mod n
+		s = new(big.Int).Mul(r, d)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, n))
+		s.Mod(s, n)
+		if s.Sign() != 0 {
+			break
+		}
 	}
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(n, s)
+	}
+
 	rb := r.Bytes()
 	sb := s.Bytes()
 	sig := make([]byte, 64)
@@ -147,6 +177,17 @@ func (pubKey PubKeyP256) VerifyBytes(msg []byte, sig []byte) (res bool) {
 	)
 }
 
+// IsLowS tells if s of the signature is not greater than half the curve order.
+// Only one of (r, s) and (r, n-s) is in this form, so checking it removes
+// signature malleability.
+func IsLowS(sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	s := new(big.Int).SetBytes(sig[32:])
+	return s.Cmp(halfOrder) <= 0
+}
+
 func (pubKey PubKeyP256) Equals(other tmc.PubKey) bool {
 	return bytes.Equal(pubKey.Bytes(), other.Bytes())
 }
@@ -1,8 +1,11 @@
 package p256
 
 import (
+	"crypto/ecdsa"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,3 +51,56 @@ func TestP256JSON(t *testing.T) {
 	require.Nil(t, err)
 	assert.True(t, pubKey.Equals(RpubKey))
 }
+
+func TestSignDeterministicP256(t *testing.T) {
+	// test vector from RFC 6979 A.2.5, P-256 with SHA-256
+	var privKey PrivKeyP256
+	priv, _ := hex.DecodeString(
+		"C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")
+	privKey.SetBytes(priv)
+	msg := []byte("sample")
+
+	k := nonceRFC6979(new(big.Int).SetBytes(priv), h(msg))()
+	assert.Equal(t,
+		"A6E3C57DD01ABE90086538398355DD4C3B17AA873382B0F24D6129493D8AAD60",
+		fmt.Sprintf("%064X", k))
+
+	sig, err := privKey.Sign(msg)
+	require.Nil(t, err)
+	r := "EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716"
+	s, _ := new(big.Int).SetString(
+		"F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8", 16)
+	// high-S in the test vector gets normalized
+	s.Sub(c.Params().N, s)
+	assert.Equal(t, r, fmt.Sprintf("%X", sig[:32]))
+	assert.Equal(t, fmt.Sprintf("%064X", s), fmt.Sprintf("%X", sig[32:]))
+
+	sig2, err := privKey.Sign(msg)
+	require.Nil(t, err)
+	assert.Equal(t, sig, sig2)
+	assert.True(t, privKey.PubKey().VerifyBytes(msg, sig))
+}
+
+func TestVerifyHighSP256(t *testing.T) {
+	privKey := GenPrivKey()
+	pubKey := privKey.PubKey()
+
+	msg := crypto.CRandBytes(128)
+	sig, err := privKey.Sign(msg)
+	require.Nil(t, err)
+	s := new(big.Int).SetBytes(sig[32:])
+	assert.True(t, s.Cmp(halfOrder) <= 0)
+	assert.True(t, pubKey.VerifyBytes(msg, sig))
+
+	assert.True(t, IsLowS(sig))
+
+	// (r, n-s) is valid as well, but not in low-S form
+	hs := new(big.Int).Sub(c.Params().N, s).Bytes()
+	malleated := make([]byte, 64)
+	copy(malleated, sig[:32])
+	copy(malleated[64-len(hs):], hs)
+	assert.True(t, ecdsa.Verify(pubKey.(PubKeyP256).ToECDSA(), h(msg),
+		new(big.Int).SetBytes(malleated[:32]), new(big.Int).SetBytes(malleated[32:])))
+	assert.True(t, pubKey.VerifyBytes(msg, malleated))
+	assert.False(t, IsLowS(malleated))
+}
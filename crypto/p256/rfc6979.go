@@ -0,0 +1,65 @@
+package p256
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"math/big"
+)
+
+// nonceRFC6979 returns a generator of deterministic nonces for the private key
+// and the message hash, as described in RFC 6979 section 3.2 with HMAC-SHA256.
+// Each call returns the next candidate in the range [1, n-1].
+func nonceRFC6979(d *big.Int, hash []byte) func() *big.Int {
+	n := c.Params().N
+	size := (n.BitLen() + 7) / 8
+
+	x := int2octets(d, size)
+	h1 := int2octets(new(big.Int).Mod(new(big.Int).SetBytes(hash), n), size)
+
+	v := make([]byte, sha256.Size)
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, sha256.Size)
+
+	k = mac(k, v, []byte{0x00}, x, h1)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, x, h1)
+	v = mac(k, v)
+
+	started := false
+	return func() *big.Int {
+		for {
+			if started {
+				k = mac(k, v, []byte{0x00})
+				v = mac(k, v)
+			}
+			started = true
+
+			t := make([]byte, 0, size)
+			for len(t) < size {
+				v = mac(k, v)
+				t = append(t, v...)
+			}
+			nonce := new(big.Int).SetBytes(t[:size])
+			if nonce.Sign() > 0 && nonce.Cmp(n) < 0 {
+				return nonce
+			}
+		}
+	}
+}
+
+func mac(key []byte, data ...[]byte) []byte {
+	m := hmac.New(sha256.New, key)
+	for _, d := range data {
+		m.Write(d)
+	}
+	return m.Sum(nil)
+}
+
+func int2octets(v *big.Int, size int) []byte {
+	b := v.Bytes()
+	out := make([]byte, size)
+	copy(out[size-len(b):], b)
+	return out
+}